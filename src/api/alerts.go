@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/alerts"
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+// maxSavedSearches is how many searches a user can save, each one is checked
+// against the providers on every interval of the scheduler
+const maxSavedSearches = 20
+
+var errTooManySavedSearches = fmt.Errorf("a user can save up to %d searches", maxSavedSearches)
+
+type saveSearchRequest struct {
+	Search         entity.FlightSearchParam `json:"search"`
+	Filters        entity.FlightQuery       `json:"filters"`
+	PriceThreshold float64                  `json:"price_threshold"`
+	Email          string                   `json:"email"`
+}
+
+// newSavedSearch validates the request and builds the saved search of the user,
+// it expires with the token of the user
+func newSavedSearch(user string, expiresAt time.Time, req saveSearchRequest) (entity.SavedSearch, error) {
+	if req.PriceThreshold <= 0 {
+		return entity.SavedSearch{}, errors.New("the price threshold must be greater than 0")
+	}
+	if _, err := time.Parse("2006-01-02", req.Search.DateDeparture); err != nil {
+		return entity.SavedSearch{}, fmt.Errorf("invalid departure date: %s", req.Search.DateDeparture)
+	}
+	if err := validateRoute(req.Search.Origin, req.Search.Destination); err != nil {
+		return entity.SavedSearch{}, err
+	}
+	// only a plain address, the alerts are sent to it
+	if req.Email != "" {
+		if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+			return entity.SavedSearch{}, fmt.Errorf("invalid email: %s", req.Email)
+		}
+	}
+	if err := validator.New().Struct(req.Filters); err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			return entity.SavedSearch{}, fmt.Errorf("the variable %s is not vaild: %s", err.Field(), err.Tag())
+		}
+	}
+
+	return entity.SavedSearch{
+		UserID:         user,
+		Search:         req.Search,
+		Query:          req.Filters,
+		PriceThreshold: req.PriceThreshold,
+		Email:          req.Email,
+		CreatedAt:      time.Now(),
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+// saveSearch stores the search when the user has not reached the limit
+func (s *Server) saveSearch(ctx context.Context, search entity.SavedSearch) (entity.SavedSearch, error) {
+	searches, err := s.savedSearches.ListSearches(ctx, search.UserID)
+	if err != nil {
+		return entity.SavedSearch{}, err
+	}
+	if len(searches) >= maxSavedSearches {
+		return entity.SavedSearch{}, errTooManySavedSearches
+	}
+	return s.savedSearches.SaveSearch(ctx, search)
+}
+
+// handleSaveSearch - saves the search of the form, the scheduler checks its price from now on
+func (s *Server) handleSaveSearch(c echo.Context) error {
+	threshold, err := strconv.ParseFloat(c.FormValue("price_threshold"), 64)
+	if err != nil {
+		log.Printf("invalid price threshold: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := saveSearchRequest{
+		Search: entity.FlightSearchParam{
+			Origin:        c.FormValue("origin"),
+			Destination:   c.FormValue("destination"),
+			DateDeparture: c.FormValue("date"),
+		},
+		Filters:        entity.DefaultFlightQuery(),
+		PriceThreshold: threshold,
+		Email:          c.FormValue("email"),
+	}
+	if err := c.Bind(&req.Filters); err != nil {
+		log.Printf("could not bind the search query: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	saved, err := newSavedSearch(userID(c), tokenExpiry(c), req)
+	if err != nil {
+		log.Printf("invalid saved search: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	_, err = s.saveSearch(c.Request().Context(), saved)
+	if errors.Is(err, errTooManySavedSearches) {
+		log.Printf("saved search not stored: %v", err)
+		return c.NoContent(http.StatusConflict)
+	}
+	if err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusSeeOther, "/public/")
+}
+
+// handleDeleteSavedSearch - removes a saved search from the home page list
+func (s *Server) handleDeleteSavedSearch(c echo.Context) error {
+	err := s.savedSearches.DeleteSearch(c.Request().Context(), userID(c), c.Param("id"))
+	if err != nil && !errors.Is(err, alerts.ErrNotFound) {
+		return err
+	}
+	return c.Redirect(http.StatusSeeOther, "/public/")
+}
+
+// handleListSavedSearchesAPI - saved searches of the authenticated user
+func (s *Server) handleListSavedSearchesAPI(c echo.Context) error {
+	searches, err := s.savedSearches.ListSearches(c.Request().Context(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, searches)
+}
+
+// handleSaveSearchAPI - saves a search with the filters and the price threshold of the alert
+func (s *Server) handleSaveSearchAPI(c echo.Context) error {
+	req := saveSearchRequest{Filters: entity.DefaultFlightQuery()}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	saved, err := newSavedSearch(userID(c), tokenExpiry(c), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	saved, err = s.saveSearch(c.Request().Context(), saved)
+	if errors.Is(err, errTooManySavedSearches) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, saved)
+}
+
+// handleDeleteSavedSearchAPI - removes a saved search, its alerts are not sent anymore
+func (s *Server) handleDeleteSavedSearchAPI(c echo.Context) error {
+	err := s.savedSearches.DeleteSearch(c.Request().Context(), userID(c), c.Param("id"))
+	if errors.Is(err, alerts.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// handleSavedSearchPricesAPI - prices observed by the scheduler for a saved search
+func (s *Server) handleSavedSearchPricesAPI(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	saved, err := s.savedSearches.GetSearch(ctx, userID(c), c.Param("id"))
+	if errors.Is(err, alerts.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	observations, err := s.savedSearches.Observations(ctx, saved.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, observations)
+}
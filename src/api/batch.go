@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const (
+	// maxBatchSearches limits the routes of a batch, they run in a single request
+	maxBatchSearches = 100
+
+	// batchWriteTimeout replaces the write timeout of the server for the batches,
+	// the response is written after the last route is searched
+	batchWriteTimeout = 5 * time.Minute
+)
+
+var batchCSVHeader = []string{
+	"origin", "destination", "date",
+	"cheapest_price", "cheapest_provider", "cheapest_duration_minutes", "cheapest_stops",
+	"fastest_price", "fastest_provider", "fastest_duration_minutes", "fastest_stops",
+	"flights", "providers", "error",
+}
+
+// handleBatchSearchAPI - searches a list of routes and returns the cheapest and fastest flight of each one.
+// The routes are a JSON body or a CSV with the columns origin,destination,date, uploaded in the "file"
+// field of a form or sent as a text/csv body. With format=csv the summaries are returned as CSV
+func (s *Server) handleBatchSearchAPI(c echo.Context) error {
+	req, err := bindBatchRequest(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if len(req.Searches) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "the batch has no searches")
+	}
+	if len(req.Searches) > maxBatchSearches {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the batch has %d searches, the maximum is %d", len(req.Searches), maxBatchSearches))
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 || concurrency > s.batchConcurrency {
+		concurrency = s.batchConcurrency
+	}
+
+	// the invalid routes are reported in their summary, the others are searched
+	summaries := make([]entity.RouteSummary, len(req.Searches))
+	valid := make([]entity.FlightSearchParam, 0, len(req.Searches))
+	validIndexes := make([]int, 0, len(req.Searches))
+	for i, search := range req.Searches {
+		if err := validateSearch(search, req.Filters); err != nil {
+			summaries[i] = entity.RouteSummary{
+				Origin:        search.Origin,
+				Destination:   search.Destination,
+				DateDeparture: search.DateDeparture,
+				Providers:     []string{},
+				Error:         err.Error(),
+			}
+			continue
+		}
+		valid = append(valid, search)
+		validIndexes = append(validIndexes, i)
+	}
+
+	extendWriteDeadline(c, batchWriteTimeout)
+	for i, summary := range s.flight.SearchBatch(c.Request().Context(), valid, req.Filters, concurrency) {
+		summaries[validIndexes[i]] = summary
+	}
+
+	switch c.QueryParam("format") {
+	case "csv":
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="batch-search.csv"`)
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+		c.Response().WriteHeader(http.StatusOK)
+		return writeBatchCSV(c.Response(), summaries)
+	case "json":
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="batch-search.json"`)
+	}
+	return c.JSON(http.StatusOK, summaries)
+}
+
+func bindBatchRequest(c echo.Context) (entity.BatchSearchRequest, error) {
+	req := entity.BatchSearchRequest{Filters: entity.DefaultFlightQuery()}
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+
+	if strings.HasPrefix(contentType, echo.MIMEApplicationJSON) {
+		return req, c.Bind(&req)
+	}
+
+	// with a CSV the filters and the concurrency are query params
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &req.Filters); err != nil {
+		return req, err
+	}
+	if value := c.QueryParam("concurrency"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil {
+			return req, fmt.Errorf("invalid concurrency: %w", err)
+		}
+		req.Concurrency = concurrency
+	}
+
+	var body io.Reader
+	switch {
+	case strings.HasPrefix(contentType, echo.MIMEMultipartForm):
+		header, err := c.FormFile("file")
+		if err != nil {
+			return req, fmt.Errorf("the CSV file is missing: %w", err)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return req, err
+		}
+		defer file.Close()
+		body = file
+	case strings.HasPrefix(contentType, "text/csv"):
+		body = c.Request().Body
+	default:
+		return req, fmt.Errorf("unsupported content type %q, use JSON or CSV", contentType)
+	}
+
+	searches, err := parseBatchCSV(body)
+	req.Searches = searches
+	return req, err
+}
+
+// parseBatchCSV reads the routes of the CSV, the header row is optional
+func parseBatchCSV(r io.Reader) ([]entity.FlightSearchParam, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var searches []entity.FlightSearchParam
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return searches, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "origin") {
+			continue
+		}
+
+		searches = append(searches, entity.FlightSearchParam{
+			Origin:        strings.TrimSpace(record[0]),
+			Destination:   strings.TrimSpace(record[1]),
+			DateDeparture: strings.TrimSpace(record[2]),
+		})
+	}
+}
+
+func writeBatchCSV(w io.Writer, summaries []entity.RouteSummary) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(batchCSVHeader); err != nil {
+		return err
+	}
+
+	for _, s := range summaries {
+		row := []string{s.Origin, s.Destination, s.DateDeparture}
+		row = append(row, flightColumns(s.Cheapest)...)
+		row = append(row, flightColumns(s.Fastest)...)
+		row = append(row, strconv.Itoa(s.Flights), strings.Join(s.Providers, ";"), s.Error)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// flightColumns returns price, provider, duration and stops, empty when there is no flight
+func flightColumns(f *entity.Flight) []string {
+	if f == nil {
+		return []string{"", "", "", ""}
+	}
+	return []string{
+		strconv.FormatFloat(f.Price, 'f', 2, 64),
+		f.ProviderName,
+		strconv.Itoa(f.DurationMinutes),
+		strconv.Itoa(f.Stops),
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchCSV(t *testing.T) {
+	searches, err := parseBatchCSV(strings.NewReader("origin,destination,date\nMadrid, Lisbon,2030-05-10\nParis,Rome,2030-05-11\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []entity.FlightSearchParam{
+		{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10"},
+		{Origin: "Paris", Destination: "Rome", DateDeparture: "2030-05-11"},
+	}, searches)
+
+	searches, err = parseBatchCSV(strings.NewReader("Madrid,Lisbon,2030-05-10\n"))
+	require.NoError(t, err)
+	assert.Len(t, searches, 1)
+
+	_, err = parseBatchCSV(strings.NewReader("Madrid,Lisbon\n"))
+	assert.Error(t, err)
+}
+
+func TestWriteBatchCSV(t *testing.T) {
+	var b bytes.Buffer
+	err := writeBatchCSV(&b, []entity.RouteSummary{
+		{
+			Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10",
+			Cheapest:  &entity.Flight{ProviderName: "google", Price: 99.5, DurationMinutes: 80},
+			Fastest:   &entity.Flight{ProviderName: "Amadeus", Price: 120, DurationMinutes: 70, Stops: 0},
+			Flights:   4,
+			Providers: []string{"google", "Amadeus"},
+		},
+		{Origin: "Madrid", Destination: "Berlin", DateDeparture: "2030-05-10", Error: "no provider answered"},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, strings.Join(batchCSVHeader, ","), lines[0])
+	assert.Equal(t, "Madrid,Lisbon,2030-05-10,99.50,google,80,0,120.00,Amadeus,70,0,4,google;Amadeus,", lines[1])
+	assert.Equal(t, "Madrid,Berlin,2030-05-10,,,,,,,,,0,,no provider answered", lines[2])
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/export"
+	services "github.com/mariajdab/flight-price/internal/flights/service"
+)
+
+// handleExportAPI - downloads all the flights of the search that match the filters,
+// format=csv has one row per segment and format=json the flights with their segments
+func (s *Server) handleExportAPI(c echo.Context) error {
+	req, query, err := bindExportSearch(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	format := c.QueryParam("format")
+	if format != "csv" && format != "json" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the format %q is not supported, use csv or json", format))
+	}
+
+	resp, flights, err := s.exportFlights(c, req, query)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	filename := exportFilename(req, format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "csv" {
+		c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+		c.Response().WriteHeader(http.StatusOK)
+		return export.FlightsCSV(c.Response(), flights)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c.Response().WriteHeader(http.StatusOK)
+	return export.FlightsJSON(c.Response(), resp, flights)
+}
+
+// handleItineraryICSAPI - iCalendar with the segments of the itinerary chosen from the search results
+func (s *Server) handleItineraryICSAPI(c echo.Context) error {
+	req, query, err := bindExportSearch(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	itinerary := c.QueryParam("itinerary")
+	if itinerary == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "the itinerary is required")
+	}
+
+	_, flights, err := s.exportFlights(c, req, query)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	for _, f := range flights {
+		if f.ItineraryID != itinerary {
+			continue
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="itinerary-%s.ics"`, itinerary))
+		c.Response().Header().Set(echo.HeaderContentType, "text/calendar; charset=utf-8")
+		c.Response().WriteHeader(http.StatusOK)
+		return export.ItineraryICS(c.Response(), f, time.Now())
+	}
+	return echo.NewHTTPError(http.StatusNotFound, "the itinerary is not in the search results")
+}
+
+func bindExportSearch(c echo.Context) (entity.FlightSearchParam, entity.FlightQuery, error) {
+	req := entity.FlightSearchParam{
+		Origin:        c.QueryParam("origin"),
+		Destination:   c.QueryParam("destination"),
+		DateDeparture: c.QueryParam("date"),
+	}
+
+	query := entity.DefaultFlightQuery()
+	if err := c.Bind(&query); err != nil {
+		return req, query, err
+	}
+	if err := validateSearch(req, query); err != nil {
+		return req, query, err
+	}
+	return req, query, nil
+}
+
+// exportFlights searches again the route, the providers answer from the cache when
+// the export follows the search of the results page
+func (s *Server) exportFlights(c echo.Context, req entity.FlightSearchParam, query entity.FlightQuery) (entity.FlightPriceResponse, []entity.Flight, error) {
+	resp := s.flight.SearchFlights(c.Request().Context(), req)
+	flights, err := services.FilteredFlights(resp, query)
+	return resp, flights, err
+}
+
+func exportFilename(req entity.FlightSearchParam, format string) string {
+	name := strings.ToLower(fmt.Sprintf("flights-%s-%s-%s", req.Origin, req.Destination, req.DateDeparture))
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+	return name + "." + format
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/jobs"
+)
+
+// maxJobSearches limits the routes of a job, each one is a search in every provider
+const maxJobSearches = 20
+
+// handleCreateSearchJobAPI - queues a multi-route or flexible date search, the
+// job is polled with its id until it finishes
+func (s *Server) handleCreateSearchJobAPI(c echo.Context) error {
+	if s.searchJobs == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "the search jobs are not enabled")
+	}
+
+	req := entity.SearchJobRequest{Filters: entity.DefaultFlightQuery()}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validateSearchJob(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	job, err := s.searchJobs.Submit(req)
+	if errors.Is(err, jobs.ErrQueueFull) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/searches/"+job.ID)
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// handleGetSearchJobAPI - progress of the job and the results found so far
+func (s *Server) handleGetSearchJobAPI(c echo.Context) error {
+	if s.searchJobs == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "the search jobs are not enabled")
+	}
+
+	job, err := s.searchJobs.Get(c.Param("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// handleCancelSearchJobAPI - stops the job, the results found until then are kept
+func (s *Server) handleCancelSearchJobAPI(c echo.Context) error {
+	if s.searchJobs == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "the search jobs are not enabled")
+	}
+
+	job, err := s.searchJobs.Cancel(c.Param("id"))
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, jobs.ErrFinished):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case err != nil:
+		return err
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+func validateSearchJob(req entity.SearchJobRequest) error {
+	if req.Flexible != nil {
+		if len(req.Searches) > 0 {
+			return errors.New("a job has either searches or a flexible search")
+		}
+		if err := validator.New().Struct(req.Flexible); err != nil {
+			for _, err := range err.(validator.ValidationErrors) {
+				return fmt.Errorf("the variable %s is not vaild: %s", err.Field(), err.Tag())
+			}
+		}
+		return validateRoute(req.Flexible.Origin, req.Flexible.Destination)
+	}
+
+	if len(req.Searches) == 0 {
+		return errors.New("a job needs searches or a flexible search")
+	}
+	if len(req.Searches) > maxJobSearches {
+		return fmt.Errorf("a job has %d searches, the maximum is %d", len(req.Searches), maxJobSearches)
+	}
+	for _, search := range req.Searches {
+		if err := validateSearch(search, req.Filters); err != nil {
+			return fmt.Errorf("invalid search %s - %s: %w", search.Origin, search.Destination, err)
+		}
+	}
+	return nil
+}
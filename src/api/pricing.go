@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/providers"
+)
+
+type priceOfferRequest struct {
+	Token string `json:"token" form:"token"`
+}
+
+// handlePriceOfferAPI - confirms the current price of an offer chosen from the search results
+func (s *Server) handlePriceOfferAPI(c echo.Context) error {
+	var req priceOfferRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.Token == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "the token of the offer is required")
+	}
+
+	priced, err := s.flight.PriceOffer(c.Request().Context(), req.Token)
+	switch {
+	case errors.Is(err, providers.ErrPricingNotSupported):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, providers.ErrOfferNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "the offer expired, search again")
+	case err != nil:
+		log.Printf("error pricing the offer %s: %v", req.Token, err)
+		return echo.NewHTTPError(http.StatusBadGateway, "the provider could not confirm the price")
+	}
+	return c.JSON(http.StatusOK, priced)
+}
+
+// handleOfferAPI - the offer of a flight as its provider sent it, to debug the mapping of the providers
+func (s *Server) handleOfferAPI(c echo.Context) error {
+	offer, err := s.flight.Offer(c.Param("token"))
+	if errors.Is(err, providers.ErrOfferNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "the offer expired, search again")
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, offer)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pricingStub is the stub provider with the original offer of each flight, its
+// flights are cheaper so the merged results keep its offers
+type pricingStub struct {
+	stubProvider
+}
+
+func (p pricingStub) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	resp, err := p.stubProvider.SearchFlights(ctx, criteria)
+	for i := range resp.Flights {
+		id := "offer-" + resp.Flights[i].Segments[0].FlightNumber
+		resp.Flights[i].Price--
+		resp.Flights[i].Ref = &entity.ProviderRef{ID: id}
+		resp.Flights[i].Raw = json.RawMessage(`{"id": "` + id + `"}`)
+	}
+	return resp, err
+}
+
+func (p pricingStub) PriceOffer(_ context.Context, offer entity.StoredOffer) (entity.PricedOffer, error) {
+	return entity.PricedOffer{
+		Provider: p.name, Token: offer.Ref.Token, Currency: entity.DefaultCurrency,
+		PreviousPrice: offer.Price, Price: 135.5, PriceChange: 135.5 - offer.Price,
+		FareRules: []entity.FareRule{{Category: "REFUND", Allowed: false}},
+	}, nil
+}
+
+func TestServer_PriceOffer(t *testing.T) {
+	server, client := newTestServer(t, pricingStub{stubProvider{name: "pricing"}}, stubProvider{name: "stub"})
+
+	resp, err := client.Get(server.URL + "/api/v1/flights/search?" + searchForm().Encode() + "&sort=duration")
+	require.NoError(t, err)
+	var result entity.FlightPriceResponse
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &result))
+	require.NotNil(t, result.Flights[0].Ref)
+	ref := *result.Flights[0].Ref
+	assert.Equal(t, "pricing", ref.Provider)
+	assert.Equal(t, "offer-1017", ref.ID)
+	assert.True(t, ref.Priceable)
+
+	priceOffer := func(t *testing.T, token string) *http.Response {
+		resp, err := client.Post(server.URL+"/api/v1/flights/price", "application/json", strings.NewReader(`{"token": "`+token+`"}`))
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("the results have the button", func(t *testing.T) {
+		resp, err := client.PostForm(server.URL+"/private/flights/search", searchForm())
+		require.NoError(t, err)
+		assert.Contains(t, readBody(t, resp), `data-token="`+ref.Token+`"`)
+	})
+
+	t.Run("confirmed price", func(t *testing.T) {
+		resp := priceOffer(t, ref.Token)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var priced entity.PricedOffer
+		require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &priced))
+		assert.Equal(t, 135.5, priced.Price)
+		assert.Equal(t, 16.5, priced.PriceChange)
+		assert.Equal(t, []entity.FareRule{{Category: "REFUND"}}, priced.FareRules)
+	})
+
+	t.Run("original offer", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/api/v1/flights/offers/" + ref.Token)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var offer entity.StoredOffer
+		require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &offer))
+		assert.Equal(t, ref, offer.Ref)
+		assert.JSONEq(t, `{"id": "offer-1017"}`, string(offer.Raw))
+	})
+
+	t.Run("expired offer", func(t *testing.T) {
+		resp := priceOffer(t, "unknown")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, err := client.Get(server.URL + "/api/v1/flights/offers/unknown")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		resp := priceOffer(t, "")
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
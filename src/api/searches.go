@@ -0,0 +1,99 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/flights/ranking"
+	"github.com/mariajdab/flight-price/internal/searches"
+)
+
+const (
+	// recentSearchesPanel is how many searches the home page shows
+	recentSearchesPanel = 5
+
+	// repeatedSearchWindow is how long the same search is not recorded again,
+	// going through the pages of the results repeats the search
+	repeatedSearchWindow = 10 * time.Minute
+)
+
+// recordSearch adds the search to the history of the user, the anonymous searches are not kept
+func (s *Server) recordSearch(c echo.Context, req entity.FlightSearchParam, query entity.FlightQuery, resp entity.FlightPriceResponse) {
+	user := tokenUserID(c)
+	if user == "" {
+		return
+	}
+
+	record := entity.SearchRecord{
+		UserID:     user,
+		Search:     req,
+		Query:      query,
+		SearchedAt: time.Now(),
+		Providers:  make([]string, 0, len(resp.FlightByProvider)),
+	}
+	// the page of the results is not part of the search, it starts again from the first one
+	record.Query.Page = 1
+	if cheapest := resp.Rankings[ranking.Cheapest]; len(cheapest) > 0 {
+		record.BestPrice = cheapest[0].Price
+	}
+	for _, r := range resp.FlightByProvider {
+		record.Providers = append(record.Providers, r.Provider)
+	}
+
+	ctx := c.Request().Context()
+	last, err := s.searchHistory.Recent(ctx, user, 1)
+	if err != nil {
+		log.Printf("error loading the recent searches: %v", err)
+	}
+	if len(last) > 0 && repeatedSearch(last[0], record) {
+		return
+	}
+
+	if err := s.searchHistory.Add(ctx, record); err != nil {
+		log.Printf("error recording the search: %v", err)
+	}
+}
+
+// repeatedSearch reports if the record is the same search and filters than the
+// last one of the user, done a moment ago
+func repeatedSearch(last, record entity.SearchRecord) bool {
+	return record.SearchedAt.Sub(last.SearchedAt) < repeatedSearchWindow &&
+		last.Search == record.Search && reflect.DeepEqual(last.Query, record.Query)
+}
+
+// userRecentSearches returns the last searches shown in the page, an error only hides the panel
+func (s *Server) userRecentSearches(c echo.Context) []entity.SearchRecord {
+	user := tokenUserID(c)
+	if user == "" {
+		return nil
+	}
+
+	records, err := s.searchHistory.Recent(c.Request().Context(), user, recentSearchesPanel)
+	if err != nil {
+		log.Printf("error loading the recent searches: %v", err)
+	}
+	return records
+}
+
+// handleSearchHistoryAPI - last searches of the authenticated user, newest first
+func (s *Server) handleSearchHistoryAPI(c echo.Context) error {
+	limit := 0
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > searches.MaxPerUser {
+			return echo.NewHTTPError(http.StatusBadRequest, "the variable limit is not vaild: it must be between 1 and "+strconv.Itoa(searches.MaxPerUser))
+		}
+	}
+
+	records, err := s.searchHistory.Recent(c.Request().Context(), userID(c), limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, records)
+}
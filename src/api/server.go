@@ -2,68 +2,292 @@ package api
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
+	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/mariajdab/flight-price/assets"
 	"github.com/mariajdab/flight-price/helper"
+	"github.com/mariajdab/flight-price/internal/alerts"
 	"github.com/mariajdab/flight-price/internal/entity"
 	services "github.com/mariajdab/flight-price/internal/flights/service"
+	"github.com/mariajdab/flight-price/internal/history"
+	"github.com/mariajdab/flight-price/internal/jobs"
+	"github.com/mariajdab/flight-price/internal/searches"
 )
 
 var funcMap = template.FuncMap{
-	"subtract": func(a, b int) int { return a - b },
+	"subtract":          func(a, b int) int { return a - b },
+	"add":               func(a, b int) int { return a + b },
+	"join":              strings.Join,
+	"static":            staticURL,
+	"bags":              formatBags,
+	"formatTime":        formatTime,
+	"formatSegmentTime": formatSegmentTime,
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04 (MST)")
+}
+
+// formatSegmentTime doesn't show the offset of an airport without time zone, it is not its real one
+func formatSegmentTime(t time.Time, zoneUnknown bool) string {
+	if t.IsZero() || !zoneUnknown {
+		return formatTime(t)
+	}
+	return t.Format("2006-01-02 15:04") + " (local time)"
+}
+
+// formatBags describes a baggage allowance, e.g. "1 bag" or "23 KG"
+func formatBags(b *entity.Baggage) string {
+	switch {
+	case b == nil:
+		return "unknown"
+	case b.Weight > 0 && b.Quantity == 0:
+		return fmt.Sprintf("%d %s", b.Weight, b.WeightUnit)
+	case b.Quantity == 1:
+		return "1 bag"
+	default:
+		return fmt.Sprintf("%d bags", b.Quantity)
+	}
 }
 
 type TemplateRenderer struct {
 	templates *template.Template
+	// fromDisk parses the templates again in every render, to edit them without restarting
+	fromDisk fs.FS
 }
 
 func (t *TemplateRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
-	return t.templates.ExecuteTemplate(w, name, data)
+	templates := t.templates
+	if t.fromDisk != nil {
+		var err error
+		if templates, err = parseTemplates(t.fromDisk); err != nil {
+			return err
+		}
+	}
+	return templates.ExecuteTemplate(w, name, data)
+}
+
+// parseTemplates parses the pages of the templates directory
+func parseTemplates(fsys fs.FS) (*template.Template, error) {
+	return template.New("").Funcs(funcMap).ParseFS(fsys, "templates/*.html")
 }
 
 // PageData holds all data passed to templates
 type PageData struct {
 	FlightResponse  *entity.FlightPriceResponse
 	SearchPerformed bool
+	Search          entity.FlightSearchParam
+	Query           entity.FlightQuery
+	SavedSearches   []entity.SavedSearch
+	RecentSearches  []entity.SearchRecord
 	Token           string
 	TokenPreview    string // First few characters of token for display
 }
 
 type Server struct {
-	httpServer *http.Server
-	flight     *services.FlightService
+	httpServer    *http.Server
+	flight        *services.FlightService
+	savedSearches alerts.Repository
+	priceHistory  history.Repository
+	searchHistory searches.Repository
+	searchJobs    *jobs.Manager
+	templates     *template.Template
+	assetsDir     string
+
+	batchConcurrency int
+}
+
+// Option customizes the server dependencies
+type Option func(*Server)
+
+// WithSavedSearches sets the repository of the saved searches, by default they are kept in memory
+func WithSavedSearches(repo alerts.Repository) Option {
+	return func(s *Server) {
+		s.savedSearches = repo
+	}
+}
+
+// WithSearchHistory sets the repository of the searches of the users, by default they are kept in memory
+func WithSearchHistory(repo searches.Repository) Option {
+	return func(s *Server) {
+		s.searchHistory = repo
+	}
+}
+
+// WithSearchJobs enables the background search jobs, the manager must be started
+func WithSearchJobs(manager *jobs.Manager) Option {
+	return func(s *Server) {
+		s.searchJobs = manager
+	}
+}
+
+// WithBatchConcurrency limits the routes of a batch search that run at the same time
+func WithBatchConcurrency(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.batchConcurrency = n
+		}
+	}
 }
 
+// WithTemplates sets the page templates, by default the embedded ones are used.
+// The templates can use the functions of TemplateFuncs
+func WithTemplates(templates *template.Template) Option {
+	return func(s *Server) {
+		s.templates = templates
+	}
+}
+
+// WithAssetsDir reads the templates and the static files from the assets directory
+// on every request instead of the embedded ones, for live editing in development
+func WithAssetsDir(dir string) Option {
+	return func(s *Server) {
+		s.assetsDir = dir
+	}
+}
+
+// TemplateFuncs returns the functions available in the page templates
+func TemplateFuncs() template.FuncMap {
+	return funcMap
+}
+
+// WithPriceHistory enables the price history endpoint
+func WithPriceHistory(repo history.Repository) Option {
+	return func(s *Server) {
+		s.priceHistory = repo
+	}
+}
+
+var jwtSecret = []byte("secret")
+
+// defaultHistoryDays is the period of the price history when the request has no days
+const defaultHistoryDays = 90
+
+// tokenLifetime is the life of the token and its cookie, the user and its
+// saved searches can't be reached after it
+const tokenLifetime = 72 * time.Hour
+
 type jwtCustomClaims struct {
 	jwt.RegisteredClaims
 }
 
+// jwtMiddleware validates the token of the cookie or the Authorization header
+func jwtMiddleware(errorHandler func(c echo.Context, err error) error) echo.MiddlewareFunc {
+	return echojwt.WithConfig(echojwt.Config{
+		NewClaimsFunc: func(c echo.Context) jwt.Claims {
+			return new(jwtCustomClaims)
+		},
+		SigningKey:   jwtSecret,
+		TokenLookup:  "cookie:jwt_token,header:Authorization:Bearer ",
+		ErrorHandler: errorHandler,
+	})
+}
+
+// userID returns the subject of the token validated by the jwt middleware
+func userID(c echo.Context) string {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	claims, ok := token.Claims.(*jwtCustomClaims)
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
+
+// tokenExpiry returns when the token validated by the jwt middleware expires
+func tokenExpiry(c echo.Context) time.Time {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return time.Time{}
+	}
+	claims, ok := token.Claims.(*jwtCustomClaims)
+	if !ok || claims.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return claims.ExpiresAt.Time
+}
+
+// tokenUserID validates the token in the routes without the jwt middleware,
+// an anonymous request returns an empty user
+func tokenUserID(c echo.Context) string {
+	value := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if cookie, err := c.Cookie("jwt_token"); err == nil && cookie.Value != "" {
+		value = cookie.Value
+	}
+	if value == "" {
+		return ""
+	}
+
+	claims := new(jwtCustomClaims)
+	_, err := jwt.ParseWithClaims(value, claims, func(t *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	if err != nil {
+		return ""
+	}
+	return claims.Subject
+}
+
+// userSavedSearches returns the saved searches shown in the page, an error only hides the list
+func (s *Server) userSavedSearches(c echo.Context) []entity.SavedSearch {
+	user := tokenUserID(c)
+	if user == "" {
+		return nil
+	}
+
+	searches, err := s.savedSearches.ListSearches(c.Request().Context(), user)
+	if err != nil {
+		log.Printf("error loading saved searches: %v", err)
+	}
+	return searches
+}
+
 // Authentication handler - generates a token and sets it as a cookie
 func (s *Server) authenticate(c echo.Context) error {
+	// the subject keys the saved searches and the history of the user, it is random
+	// because there are no credentials to prove a name belongs to whoever types it
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	subject := "user-" + hex.EncodeToString(b)
+
+	expiresAt := time.Now().Add(tokenLifetime)
 	claims := &jwtCustomClaims{
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Generate encoded token
-	t, err := token.SignedString([]byte("secret"))
+	t, err := token.SignedString(jwtSecret)
 	if err != nil {
 		return err
 	}
@@ -72,7 +296,7 @@ func (s *Server) authenticate(c echo.Context) error {
 	cookie := new(http.Cookie)
 	cookie.Name = "jwt_token"
 	cookie.Value = t
-	cookie.Expires = time.Now().Add(24 * time.Hour)
+	cookie.Expires = expiresAt
 	cookie.Path = "/"
 	c.SetCookie(cookie)
 
@@ -87,9 +311,13 @@ func (s *Server) homePage(c echo.Context) error {
 	if err == nil && cookie.Value != "" {
 		tokenValue = cookie.Value
 	}
+
 	return c.Render(http.StatusOK, "index.html", PageData{
-		Token:        tokenValue,
-		TokenPreview: tokenValue,
+		Query:          entity.DefaultFlightQuery(),
+		SavedSearches:  s.userSavedSearches(c),
+		RecentSearches: s.userRecentSearches(c),
+		Token:          tokenValue,
+		TokenPreview:   tokenValue,
 	})
 }
 
@@ -112,31 +340,25 @@ func (s *Server) simpleCheck(c echo.Context) error {
 // handleFlightSearch - handles the POST request from the flight search form
 func (s *Server) handleFlightSearch(c echo.Context) error {
 	// Token is valid, process the search request
-	origin := c.FormValue("origin")
-	destination := c.FormValue("destination")
-	date := c.FormValue("date")
-
 	req := entity.FlightSearchParam{
-		Origin:        origin,
-		Destination:   destination,
-		DateDeparture: date,
+		Origin:        c.FormValue("origin"),
+		Destination:   c.FormValue("destination"),
+		DateDeparture: c.FormValue("date"),
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		for _, err := range err.(validator.ValidationErrors) {
-			log.Printf("the variable %s is not vaild: %s\n\n", err.Tag(), err.Field())
-			return c.NoContent(http.StatusBadRequest)
-		}
+	query := entity.DefaultFlightQuery()
+	if err := c.Bind(&query); err != nil {
+		log.Printf("could not bind the search query: %v", err)
+		return c.NoContent(http.StatusBadRequest)
 	}
 
-	orignCode := helper.CityToIATACode(origin)
-	destCode := helper.CityToIATACode(destination)
-
-	if orignCode == "" || destCode == "" {
-		log.Printf("warning: city not support: %s %s", orignCode, destCode)
+	resp, err := s.searchFlights(c.Request().Context(), req, query)
+	if err != nil {
+		log.Printf("invalid flight search: %v", err)
 		return c.NoContent(http.StatusBadRequest)
 	}
+	// the live results of the page recorded the same search a moment ago, it is not repeated
+	s.recordSearch(c, req, query, resp)
 
 	cookie, err := c.Cookie("jwt_token")
 	tokenValue := ""
@@ -144,8 +366,6 @@ func (s *Server) handleFlightSearch(c echo.Context) error {
 		tokenValue = cookie.Value
 	}
 
-	resp := s.flight.SearchFlights(context.Background(), req)
-
 	result := &resp
 	if len(result.FlightByProvider) == 0 {
 		result = nil
@@ -154,12 +374,157 @@ func (s *Server) handleFlightSearch(c echo.Context) error {
 	return c.Render(http.StatusOK, "index.html", PageData{
 		FlightResponse:  result,
 		SearchPerformed: true,
+		Search:          req,
+		Query:           query,
+		SavedSearches:   s.userSavedSearches(c),
+		RecentSearches:  s.userRecentSearches(c),
 		Token:           tokenValue,
 		TokenPreview:    tokenValue,
 	})
 }
 
-func New(flightService *services.FlightService, tls *tls.Config) *Server {
+// handleFlightSearchAPI - same search than the form but the result is returned as JSON
+func (s *Server) handleFlightSearchAPI(c echo.Context) error {
+	req := entity.FlightSearchParam{
+		Origin:        c.QueryParam("origin"),
+		Destination:   c.QueryParam("destination"),
+		DateDeparture: c.QueryParam("date"),
+	}
+
+	query := entity.DefaultFlightQuery()
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := s.searchFlights(c.Request().Context(), req, query)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	s.recordSearch(c, req, query, resp)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// searchFlights validates the search and runs it against the providers
+func (s *Server) searchFlights(ctx context.Context, req entity.FlightSearchParam, query entity.FlightQuery) (entity.FlightPriceResponse, error) {
+	if err := validateSearch(req, query); err != nil {
+		return entity.FlightPriceResponse{}, err
+	}
+
+	resp := s.flight.SearchFlights(ctx, req)
+
+	return services.ApplyQuery(resp, query)
+}
+
+// longSearchWriteTimeout replaces the write timeout of the server for the
+// searches of several dates, they wait for every date of the range
+const longSearchWriteTimeout = 2 * time.Minute
+
+// extendWriteDeadline gives more time than the write timeout of the server to
+// the handlers that run many searches before answering
+func extendWriteDeadline(c echo.Context, timeout time.Duration) {
+	if err := http.NewResponseController(c.Response()).SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		log.Printf("warning: could not extend the write deadline: %v", err)
+	}
+}
+
+// handleFlexibleSearchAPI - searches several days and returns the cheapest price of each one
+func (s *Server) handleFlexibleSearchAPI(c echo.Context) error {
+	var req entity.FlexibleSearchParam
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the variable %s is not vaild: %s", err.Field(), err.Tag()))
+		}
+	}
+	if err := validateRoute(req.Origin, req.Destination); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	extendWriteDeadline(c, longSearchWriteTimeout)
+	resp, err := s.flight.SearchFlexibleDates(c.Request().Context(), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// handlePriceCalendarAPI - cheapest price of each day of a month, used by the calendar of the search page
+func (s *Server) handlePriceCalendarAPI(c echo.Context) error {
+	origin := c.QueryParam("origin")
+	destination := c.QueryParam("destination")
+	month := c.QueryParam("month")
+
+	if err := validateRoute(origin, destination); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	extendWriteDeadline(c, longSearchWriteTimeout)
+	calendar, err := s.flight.PriceCalendar(c.Request().Context(), origin, destination, month)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, calendar)
+}
+
+// handlePriceHistoryAPI - prices observed for a route and their trend, to know if it is a good time to book
+func (s *Server) handlePriceHistoryAPI(c echo.Context) error {
+	if s.priceHistory == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "the price history is not enabled")
+	}
+
+	var query entity.PriceHistoryQuery
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validator.New().Struct(query); err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("the variable %s is not vaild: %s", err.Field(), err.Tag()))
+		}
+	}
+
+	days := query.Days
+	if days == 0 {
+		days = defaultHistoryDays
+	}
+
+	records, err := s.priceHistory.Prices(c.Request().Context(), query, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, history.Summarize(query, records))
+}
+
+func validateSearch(req entity.FlightSearchParam, query entity.FlightQuery) error {
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return err
+	}
+	if err := validate.Struct(query); err != nil {
+		for _, err := range err.(validator.ValidationErrors) {
+			return fmt.Errorf("the variable %s is not vaild: %s", err.Field(), err.Tag())
+		}
+	}
+
+	return validateRoute(req.Origin, req.Destination)
+}
+
+func validateRoute(origin, destination string) error {
+	orignCode := helper.CityToIATACode(origin)
+	destCode := helper.CityToIATACode(destination)
+
+	if orignCode == "" || destCode == "" {
+		return fmt.Errorf("city not supported: %s %s", origin, destination)
+	}
+	return nil
+}
+
+func New(flightService *services.FlightService, tls *tls.Config, opts ...Option) *Server {
 	e := echo.New()
 
 	// Set up middleware
@@ -167,12 +532,6 @@ func New(flightService *services.FlightService, tls *tls.Config) *Server {
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
-	// Initialize template renderer
-	renderer := &TemplateRenderer{
-		templates: template.Must(template.New("").Funcs(funcMap).ParseGlob("assets/templates/*.html")),
-	}
-	e.Renderer = renderer
-
 	server := &http.Server{
 		Addr:         ":8443",
 		Handler:      e,
@@ -183,10 +542,38 @@ func New(flightService *services.FlightService, tls *tls.Config) *Server {
 	}
 
 	srv := &Server{
-		httpServer: server,
-		flight:     flightService,
+		httpServer:    server,
+		flight:        flightService,
+		savedSearches: alerts.NewInMemoryRepository(),
+		searchHistory: searches.NewInMemoryRepository(),
+
+		batchConcurrency: services.DefaultBatchConcurrency,
+	}
+	for _, opt := range opts {
+		opt(srv)
 	}
 
+	// Initialize template renderer, the injected templates are never reloaded
+	var assetsFS fs.FS = assets.FS
+	renderer := &TemplateRenderer{templates: srv.templates}
+	if srv.assetsDir != "" {
+		log.Printf("loading the templates and the static files from %s", srv.assetsDir)
+		assetsFS = os.DirFS(srv.assetsDir)
+		if srv.templates == nil {
+			renderer.fromDisk = assetsFS
+		}
+	}
+	if renderer.templates == nil {
+		renderer.templates = template.Must(parseTemplates(assetsFS))
+	}
+	e.Renderer = renderer
+
+	static, err := staticHandler(assetsFS, srv.assetsDir != "")
+	if err != nil {
+		log.Fatalf("Error on static files: %v", err)
+	}
+	e.GET("/static/*", static)
+
 	public := e.Group("/public")
 	public.GET("/", srv.homePage)
 	public.POST("/auth", srv.authenticate)
@@ -196,14 +583,49 @@ func New(flightService *services.FlightService, tls *tls.Config) *Server {
 	private := e.Group("/private")
 	private.POST("/flights/search", srv.handleFlightSearch)
 
-	index := e.Group("/")
-	index.GET("/", func(c echo.Context) error {
-		return c.Redirect(http.StatusOK, "/public/")
+	// the saved searches belong to the user of the token, without a valid one
+	// the page goes back to the home page to authenticate again
+	savedPages := private.Group("/saved-searches", jwtMiddleware(func(c echo.Context, err error) error {
+		return c.Redirect(http.StatusSeeOther, "/public/")
+	}))
+	savedPages.POST("", srv.handleSaveSearch)
+	savedPages.POST("/:id/delete", srv.handleDeleteSavedSearch)
+
+	apiV1 := e.Group("/api/v1")
+	apiV1.GET("/flights/search", srv.handleFlightSearchAPI)
+	apiV1.GET("/flights/stream", srv.handleFlightSearchStream)
+	apiV1.GET("/flights/flexible", srv.handleFlexibleSearchAPI)
+	apiV1.POST("/flights/batch", srv.handleBatchSearchAPI)
+	apiV1.GET("/flights/calendar", srv.handlePriceCalendarAPI)
+	apiV1.GET("/flights/history", srv.handlePriceHistoryAPI)
+	apiV1.GET("/flights/export", srv.handleExportAPI)
+	apiV1.GET("/flights/itinerary.ics", srv.handleItineraryICSAPI)
+	apiV1.POST("/flights/price", srv.handlePriceOfferAPI)
+	apiV1.GET("/flights/offers/:token", srv.handleOfferAPI)
+
+	apiV1.POST("/searches", srv.handleCreateSearchJobAPI)
+	apiV1.GET("/searches/:id", srv.handleGetSearchJobAPI)
+	apiV1.DELETE("/searches/:id", srv.handleCancelSearchJobAPI)
+
+	me := apiV1.Group("/me", jwtMiddleware(nil))
+	me.GET("/searches", srv.handleSearchHistoryAPI)
+	me.GET("/saved-searches", srv.handleListSavedSearchesAPI)
+	me.POST("/saved-searches", srv.handleSaveSearchAPI)
+	me.DELETE("/saved-searches/:id", srv.handleDeleteSavedSearchAPI)
+	me.GET("/saved-searches/:id/prices", srv.handleSavedSearchPricesAPI)
+
+	e.GET("/", func(c echo.Context) error {
+		return c.Redirect(http.StatusSeeOther, "/public/")
 	})
 
 	return srv
 }
 
+// Handler returns the routes of the server, to serve them without TLS in the tests
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
 func (s *Server) Start() error {
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,496 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/entity"
+	services "github.com/mariajdab/flight-price/internal/flights/service"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDate = "2030-06-01"
+
+type stubProvider struct {
+	name string
+	err  error
+}
+
+func (p stubProvider) Name() string { return p.name }
+
+func (p stubProvider) SearchFlights(_ context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	if p.err != nil {
+		return entity.FlightSearchResponse{}, p.err
+	}
+
+	madrid := time.FixedZone("CEST", 2*60*60)
+	lisbon := time.FixedZone("WEST", 60*60)
+	direct := entity.Flight{
+		Price:           120,
+		DurationMinutes: 80,
+		CheckedBags:     &entity.Baggage{Quantity: 1},
+		FareBrand:       "CLASSIC",
+		Segments: []entity.Segment{{
+			DepartureAirport: "MAD", DestinationAirport: "LIS",
+			DepartureTime: time.Date(2030, 6, 1, 7, 0, 0, 0, madrid), ArrivalTime: time.Date(2030, 6, 1, 7, 20, 0, 0, lisbon),
+			MarketingCarrier: "TP", FlightNumber: "1017",
+		}},
+	}
+	connection := entity.Flight{
+		Price:           95,
+		DurationMinutes: 240,
+		Stops:           1,
+		CheckedBags:     &entity.Baggage{},
+		Segments: []entity.Segment{
+			{
+				DepartureAirport: "MAD", DestinationAirport: "OPO",
+				DepartureTime: time.Date(2030, 6, 1, 9, 0, 0, 0, madrid), ArrivalTime: time.Date(2030, 6, 1, 9, 15, 0, 0, lisbon),
+				MarketingCarrier: "IB", FlightNumber: "3100",
+			},
+			{
+				DepartureAirport: "OPO", DestinationAirport: "LIS",
+				DepartureTime: time.Date(2030, 6, 1, 11, 0, 0, 0, lisbon), ArrivalTime: time.Date(2030, 6, 1, 12, 0, 0, 0, lisbon),
+				MarketingCarrier: "TP", FlightNumber: "1941",
+			},
+		},
+	}
+
+	return entity.FlightSearchResponse{
+		Provider: p.name,
+		Currency: entity.DefaultCurrency,
+		Flights:  []entity.Flight{direct, connection},
+		Cheapest: connection,
+		Fastest:  direct,
+	}, nil
+}
+
+// newTestServer serves the embedded templates with the stub providers, the
+// client keeps the cookies and doesn't follow the redirects
+func newTestServer(t *testing.T, flightProviders ...providers.Flight) (*httptest.Server, *http.Client) {
+	t.Helper()
+
+	srv := New(services.NewFlightService(flightProviders...), nil)
+	server := httptest.NewServer(srv.Handler())
+	t.Cleanup(server.Close)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return server, client
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func mustURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
+
+func searchForm() url.Values {
+	return url.Values{"origin": {"Madrid"}, "destination": {"Lisbon"}, "date": {testDate}}
+}
+
+func TestServer_AuthSearchAndLogout(t *testing.T) {
+	server, client := newTestServer(t, stubProvider{name: "stub"})
+
+	resp, err := client.Get(server.URL + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/public/", resp.Header.Get("Location"))
+
+	resp, err = client.Get(server.URL + "/public/")
+	require.NoError(t, err)
+	page := readBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, page, `action="/public/auth"`)
+	assert.NotContains(t, page, `id="search-form"`)
+
+	resp, err = client.PostForm(server.URL+"/public/auth", url.Values{"username": {"maria"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	require.Len(t, client.Jar.Cookies(mustURL(t, server.URL)), 1)
+
+	resp, err = client.Get(server.URL + "/public/")
+	require.NoError(t, err)
+	page = readBody(t, resp)
+	assert.Contains(t, page, `id="search-form"`)
+	assert.Contains(t, page, `href="/public/logout"`)
+
+	resp, err = client.PostForm(server.URL+"/private/flights/search", searchForm())
+	require.NoError(t, err)
+	page = readBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, page, "All Flights (2)")
+	assert.Contains(t, page, "TP1017")
+	assert.Contains(t, page, "IB3100")
+	assert.Contains(t, page, "Price: $95")
+	assert.Contains(t, page, "Fare: CLASSIC")
+	assert.Contains(t, page, "Checked: 1 bag, cabin: unknown")
+	assert.Contains(t, page, `value="Madrid"`)
+	// the search is in the recent searches of the user
+	assert.Contains(t, page, "Madrid - Lisbon")
+
+	resp, err = client.Get(server.URL + "/api/v1/me/searches")
+	require.NoError(t, err)
+	var records []entity.SearchRecord
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &records))
+	require.Len(t, records, 1)
+	// the typed username is not trusted, the user gets a random id
+	assert.True(t, strings.HasPrefix(records[0].UserID, "user-"), records[0].UserID)
+	assert.Equal(t, 95.0, records[0].BestPrice)
+
+	// logging in with the same username doesn't give access to the searches of the user
+	otherJar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	other := &http.Client{Jar: otherJar, CheckRedirect: client.CheckRedirect}
+	resp, err = other.PostForm(server.URL+"/public/auth", url.Values{"username": {"maria"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = other.Get(server.URL + "/api/v1/me/searches")
+	require.NoError(t, err)
+	records = nil
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &records))
+	assert.Empty(t, records)
+
+	resp, err = client.Get(server.URL + "/public/logout")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Empty(t, client.Jar.Cookies(mustURL(t, server.URL)))
+
+	resp, err = client.Get(server.URL + "/api/v1/me/searches")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestServer_SearchAPI(t *testing.T) {
+	server, client := newTestServer(t, stubProvider{name: "stub"}, stubProvider{name: "broken", err: errors.New("unavailable")})
+
+	resp, err := client.Get(server.URL + "/api/v1/flights/search?" + searchForm().Encode() + "&sort=duration&page_size=1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+
+	var result entity.FlightPriceResponse
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &result))
+	assert.Equal(t, 95.0, result.Cheapest.Price)
+	assert.Equal(t, 80, result.Fastest.DurationMinutes)
+	require.Len(t, result.Flights, 1)
+	assert.Equal(t, 80, result.Flights[0].DurationMinutes)
+	assert.Equal(t, 2, result.Pagination.TotalFlights)
+	// the failing provider doesn't break the search
+	require.Len(t, result.FlightByProvider, 1)
+	assert.Equal(t, "stub", result.FlightByProvider[0].Provider)
+
+	resp, err = client.Get(server.URL + "/api/v1/flights/search?" + searchForm().Encode() + "&checked_bag=true")
+	require.NoError(t, err)
+	result = entity.FlightPriceResponse{}
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &result))
+	require.Len(t, result.Flights, 1)
+	assert.Equal(t, 120.0, result.Flights[0].Price)
+	assert.Equal(t, &entity.Baggage{Quantity: 1}, result.Flights[0].CheckedBags)
+
+	// the connection costs 125 with a bag of 30, the direct flight includes one
+	resp, err = client.Get(server.URL + "/api/v1/flights/search?" + searchForm().Encode() + "&sort=price_with_bag&checked_bag_price=30")
+	require.NoError(t, err)
+	result = entity.FlightPriceResponse{}
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &result))
+	require.Len(t, result.Flights, 2)
+	assert.Equal(t, 120.0, result.Flights[0].Price)
+	assert.Equal(t, 120.0, result.Rankings["cheapest-with-bag"][0].Price)
+}
+
+func TestServer_RecordsRepeatedSearchesOnce(t *testing.T) {
+	server, client := newTestServer(t, stubProvider{name: "stub"})
+	resp, err := client.PostForm(server.URL+"/public/auth", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the links of the pages post the same search again
+	for _, page := range []string{"1", "2", "1"} {
+		form := searchForm()
+		form.Set("page_size", "1")
+		form.Set("page", page)
+		resp, err = client.PostForm(server.URL+"/private/flights/search", form)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	form := searchForm()
+	form.Set("page_size", "1")
+	form.Set("sort", "duration")
+	resp, err = client.PostForm(server.URL+"/private/flights/search", form)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the stream of the live results records the search, the page posted after it is the same search
+	resp, err = client.Get(server.URL + "/api/v1/flights/stream?" + searchForm().Encode() + "&max_stops=1")
+	require.NoError(t, err)
+	assert.Contains(t, readBody(t, resp), "event: done")
+	form = searchForm()
+	form.Set("max_stops", "1")
+	resp, err = client.PostForm(server.URL+"/private/flights/search", form)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = client.Get(server.URL + "/api/v1/me/searches")
+	require.NoError(t, err)
+	var records []entity.SearchRecord
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &records))
+	require.Len(t, records, 3)
+	assert.Equal(t, 1, records[0].Query.MaxStops)
+	assert.Equal(t, "duration", records[1].Query.SortBy)
+	assert.Equal(t, 1, records[2].Query.Page)
+}
+
+func TestServer_SavedSearches(t *testing.T) {
+	server, client := newTestServer(t, stubProvider{name: "stub"})
+	resp, err := client.PostForm(server.URL+"/public/auth", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the alerts can't be sent to an address with other headers
+	invalid := `{"search": {"origin": "Madrid", "destination": "Lisbon", "date": "` + testDate + `"}, "price_threshold": 100, "email": "maria@example.com\r\nBcc: all@example.com"}`
+	resp, err = client.Post(server.URL+"/api/v1/me/saved-searches", echo.MIMEApplicationJSON, strings.NewReader(invalid))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	body := `{"search": {"origin": "Madrid", "destination": "Lisbon", "date": "` + testDate + `"}, "price_threshold": 100, "email": "maria@example.com"}`
+	for i := 0; i < maxSavedSearches; i++ {
+		resp, err = client.Post(server.URL+"/api/v1/me/saved-searches", echo.MIMEApplicationJSON, strings.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		// the search expires with the token of the user
+		var saved entity.SavedSearch
+		require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &saved))
+		assert.WithinDuration(t, time.Now().Add(tokenLifetime), saved.ExpiresAt, time.Minute)
+		assert.Equal(t, "maria@example.com", saved.Email)
+	}
+
+	resp, err = client.Post(server.URL+"/api/v1/me/saved-searches", echo.MIMEApplicationJSON, strings.NewReader(body))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Contains(t, readBody(t, resp), "up to 20 searches")
+}
+
+func TestServer_ErrorFlows(t *testing.T) {
+	server, client := newTestServer(t, stubProvider{name: "stub"})
+
+	t.Run("unsupported city", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/api/v1/flights/search?origin=Atlantis&destination=Lisbon&date=" + testDate)
+		require.NoError(t, err)
+		var body map[string]string
+		require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &body))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "city not supported: Atlantis Lisbon", body["message"])
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/api/v1/flights/search?" + searchForm().Encode() + "&sort=color")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, readBody(t, resp), "SortBy")
+	})
+
+	t.Run("invalid form search", func(t *testing.T) {
+		form := searchForm()
+		form.Set("origin", "Atlantis")
+		resp, err := client.PostForm(server.URL+"/private/flights/search", form)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("saved search without token", func(t *testing.T) {
+		form := searchForm()
+		form.Set("price_threshold", "100")
+		resp, err := client.PostForm(server.URL+"/private/saved-searches", form)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+		assert.Equal(t, "/public/", resp.Header.Get("Location"))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/me/saved-searches", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer not-a-token")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("disabled feature", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/api/v1/searches/unknown")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Contains(t, readBody(t, resp), "not enabled")
+	})
+
+	t.Run("unknown itinerary", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/api/v1/flights/itinerary.ics?" + searchForm().Encode() + "&itinerary=unknown")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestServer_InjectedTemplates(t *testing.T) {
+	templates := template.Must(template.New("index.html").Parse(`{{if .Token}}user{{else}}guest{{end}}`))
+	srv := New(services.NewFlightService(), nil, WithTemplates(templates))
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "guest", strings.TrimSpace(rec.Body.String()))
+}
+
+func TestServer_StaticFiles(t *testing.T) {
+	server, client := newTestServer(t)
+
+	resp, err := client.Get(server.URL + "/public/")
+	require.NoError(t, err)
+	page := readBody(t, resp)
+	assert.NotContains(t, page, "cdn")
+	assert.Contains(t, page, `href="/static/css/app.css?v=`+staticVersion("css/app.css")+`"`)
+	assert.Contains(t, page, `src="/static/js/app.js?v=`+staticVersion("js/app.js")+`"`)
+
+	resp, err = client.Get(server.URL + staticURL("css/app.css"))
+	require.NoError(t, err)
+	assert.Contains(t, readBody(t, resp), ".calendar-grid")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/css")
+	assert.Equal(t, versionedCacheControl, resp.Header.Get("Cache-Control"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/static/css/app.css", nil)
+	require.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, staticCacheControl, resp.Header.Get("Cache-Control"))
+
+	for _, path := range []string{"/static/", "/static/css/", "/static/missing.js"} {
+		resp, err = client.Get(server.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+
+	// the templates are not static files
+	resp, err = client.Get(server.URL + "/static/../templates/index.html")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_AssetsFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "static"), 0o755))
+	page := filepath.Join(dir, "templates", "index.html")
+	require.NoError(t, os.WriteFile(page, []byte(`first`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "static", "app.css"), []byte(`body {}`), 0o644))
+
+	srv := New(services.NewFlightService(), nil, WithAssetsDir(dir))
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	assert.Equal(t, "first", get("/public/").Body.String())
+	// the templates are read again after an edit
+	require.NoError(t, os.WriteFile(page, []byte(`second`), 0o644))
+	assert.Equal(t, "second", get("/public/").Body.String())
+
+	rec := get("/static/app.css")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
+
+// slowProvider answers like the stub after the delay
+type slowProvider struct {
+	stubProvider
+	delay time.Duration
+}
+
+func (p slowProvider) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	time.Sleep(p.delay)
+	return p.stubProvider.SearchFlights(ctx, criteria)
+}
+
+func TestServer_LongSearchesOutliveTheWriteTimeout(t *testing.T) {
+	flightService := services.NewFlightService(slowProvider{stubProvider: stubProvider{name: "slow"}, delay: 200 * time.Millisecond})
+	flightService.SetDateConcurrency(services.MaxFlexibleDays)
+	srv := New(flightService, nil)
+	server := httptest.NewUnstartedServer(srv.Handler())
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	t.Run("flexible dates", func(t *testing.T) {
+		resp, err := server.Client().Get(server.URL + "/api/v1/flights/flexible?" + searchForm().Encode() + "&flex_days=1")
+		require.NoError(t, err)
+		var result entity.FlexibleSearchResponse
+		require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &result))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, result.Calendar, 3)
+	})
+	t.Run("price calendar", func(t *testing.T) {
+		resp, err := server.Client().Get(server.URL + "/api/v1/flights/calendar?origin=Madrid&destination=Lisbon&month=2030-06")
+		require.NoError(t, err)
+		var result entity.PriceCalendar
+		require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &result))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, result.Days, 30)
+	})
+	t.Run("batch", func(t *testing.T) {
+		body := `{"searches": [{"origin": "Madrid", "destination": "Lisbon", "date": "` + testDate + `"}, {"origin": "Lisbon", "destination": "Madrid", "date": "` + testDate + `"}]}`
+		resp, err := server.Client().Post(server.URL+"/api/v1/flights/batch", echo.MIMEApplicationJSON, strings.NewReader(body))
+		require.NoError(t, err)
+		var result []entity.RouteSummary
+		require.NoError(t, json.Unmarshal([]byte(readBody(t, resp)), &result))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, result, 2)
+	})
+}
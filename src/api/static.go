@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/assets"
+)
+
+const (
+	// the urls with the version of the file never change, the others are checked again after an hour
+	versionedCacheControl = "public, max-age=31536000, immutable"
+	staticCacheControl    = "public, max-age=3600"
+)
+
+var staticVersions sync.Map
+
+// staticVersion is a short hash of the embedded file, empty when the file doesn't exist
+func staticVersion(name string) string {
+	if version, ok := staticVersions.Load(name); ok {
+		return version.(string)
+	}
+
+	data, err := fs.ReadFile(assets.FS, "static/"+name)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	version := hex.EncodeToString(sum[:4])
+	staticVersions.Store(name, version)
+	return version
+}
+
+// staticURL is the url of a static file with its version, so a new release
+// doesn't serve the files cached by the browsers
+func staticURL(name string) string {
+	url := "/static/" + name
+	if version := staticVersion(name); version != "" {
+		url += "?v=" + version
+	}
+	return url
+}
+
+// staticHandler serves the files of the static directory, the files read from
+// disk in development are never cached
+func staticHandler(fsys fs.FS, fromDisk bool) (echo.HandlerFunc, error) {
+	static, err := fs.Sub(fsys, "static")
+	if err != nil {
+		return nil, err
+	}
+	files := http.FileServerFS(static)
+
+	return echo.WrapHandler(http.StripPrefix("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// no directory listings
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch {
+		case fromDisk:
+			w.Header().Set(echo.HeaderCacheControl, "no-cache")
+		case r.URL.Query().Get("v") != "":
+			w.Header().Set(echo.HeaderCacheControl, versionedCacheControl)
+		default:
+			w.Header().Set(echo.HeaderCacheControl, staticCacheControl)
+		}
+		if !fromDisk {
+			if version := staticVersion(r.URL.Path); version != "" {
+				w.Header().Set("ETag", `"`+version+`"`)
+			}
+		}
+		files.ServeHTTP(w, r)
+	}))), nil
+}
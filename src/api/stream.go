@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mariajdab/flight-price/internal/entity"
+	services "github.com/mariajdab/flight-price/internal/flights/service"
+)
+
+// streamWriteTimeout replaces the write timeout of the server for the streamed
+// searches, the slowest provider can take longer than a normal response
+const streamWriteTimeout = time.Minute
+
+// handleFlightSearchStream - server-sent events with the results of each provider as it answers.
+// The "provider" events have the flights of one provider and the best flights so far, the last
+// event is "done" with the merged response after the filters, or "search-error"
+func (s *Server) handleFlightSearchStream(c echo.Context) error {
+	req := entity.FlightSearchParam{
+		Origin:        c.QueryParam("origin"),
+		Destination:   c.QueryParam("destination"),
+		DateDeparture: c.QueryParam("date"),
+	}
+
+	query := entity.DefaultFlightQuery()
+	if err := c.Bind(&query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validateSearch(req, query); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	w := c.Response()
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		log.Printf("warning: could not extend the write deadline of the stream: %v", err)
+	}
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	ctx := c.Request().Context()
+	resp := s.flight.SearchFlightsStream(ctx, req, func(update entity.SearchUpdate) {
+		if err := writeEvent(w, "provider", update); err != nil {
+			log.Printf("error writing the search stream: %v", err)
+		}
+	})
+	if ctx.Err() != nil {
+		return nil // the client went away
+	}
+
+	resp, err := services.ApplyQuery(resp, query)
+	if err != nil {
+		return writeEvent(w, "search-error", map[string]string{"message": err.Error()})
+	}
+	s.recordSearch(c, req, query, resp)
+
+	return writeEvent(w, "done", resp)
+}
+
+// writeEvent sends a server-sent event with the JSON of data
+func writeEvent(w *echo.Response, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,9 @@
+// Package assets embeds the page templates and the static files in the binary
+package assets
+
+import "embed"
+
+// FS has the templates in templates/ and the CSS and JS in static/
+//
+//go:embed templates static
+var FS embed.FS
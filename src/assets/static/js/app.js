@@ -0,0 +1,205 @@
+// Set default date to tomorrow
+document.addEventListener('DOMContentLoaded', function() {
+    const tomorrow = new Date();
+    tomorrow.setDate(tomorrow.getDate() + 1);
+
+    const dateInput = document.getElementById('date');
+    if (dateInput && !dateInput.value) {
+        dateInput.valueAsDate = tomorrow;
+    }
+
+    const searchForm = document.getElementById('search-form');
+    if (searchForm && window.EventSource) {
+        searchForm.addEventListener('submit', streamSearch);
+    }
+
+    const monthInput = document.getElementById('calendar-month');
+    if (monthInput) {
+        monthInput.value = (dateInput && dateInput.value ? dateInput.value : tomorrow.toISOString()).slice(0, 7);
+        document.getElementById('calendar-load').addEventListener('click', loadPriceCalendar);
+    }
+
+    document.querySelectorAll('.confirm-price').forEach(function(button) {
+        button.addEventListener('click', confirmPrice);
+    });
+});
+
+// Live results: shows each provider as soon as it answers, then loads the
+// full results page, which comes from the cache of the search
+function streamSearch(event) {
+    if (!event.submitter || event.submitter.id !== 'search-button') {
+        return; // save search and pagination post the form as usual
+    }
+    event.preventDefault();
+
+    const form = event.target;
+    const params = new URLSearchParams(new FormData(form));
+    ['token', 'price_threshold', 'page'].forEach(function(name) { params.delete(name); });
+
+    const panel = document.getElementById('live-results');
+    const providers = document.getElementById('live-providers');
+    panel.classList.remove('d-none');
+    providers.innerHTML = '';
+    document.getElementById('live-cheapest').textContent = 'Cheapest: waiting for the providers...';
+    document.getElementById('live-fastest').textContent = 'Fastest: waiting for the providers...';
+
+    const source = new EventSource('/api/v1/flights/stream?' + params.toString());
+    const finish = function() {
+        source.close();
+        form.submit();
+    };
+
+    source.addEventListener('provider', function(e) {
+        const update = JSON.parse(e.data);
+        const item = document.createElement('li');
+        item.className = 'list-group-item';
+        if (update.failed) {
+            item.textContent = update.provider + ': no answer';
+        } else {
+            const prices = update.flights.map(function(f) { return f.price; });
+            item.textContent = update.provider + ': ' + update.flights.length + ' flights' +
+                (prices.length ? ', from $' + Math.min.apply(null, prices) : '');
+        }
+        providers.appendChild(item);
+
+        panel.querySelector('h4').textContent = 'Searching providers... (' + update.providersDone + ' of ' + update.providersTotal + ')';
+        if (update.cheapest.price) {
+            document.getElementById('live-cheapest').textContent = 'Cheapest so far: $' + update.cheapest.price + ' with ' + update.cheapest.provider_name;
+            document.getElementById('live-fastest').textContent = 'Fastest so far: ' + update.fastest.total_duration_minutes + ' min with ' + update.fastest.provider_name;
+        }
+    });
+    source.addEventListener('done', finish);
+    source.addEventListener('search-error', finish);
+    source.onerror = finish;
+}
+
+// Price calendar: cheapest price per day coloured from green (cheap) to red (expensive)
+function loadPriceCalendar() {
+    const origin = document.getElementById('origin').value;
+    const destination = document.getElementById('destination').value;
+    const month = document.getElementById('calendar-month').value;
+    const status = document.getElementById('calendar-status');
+    const grid = document.getElementById('calendar-grid');
+
+    if (!origin || !destination || !month) {
+        status.textContent = 'Fill in the origin, the destination and the month.';
+        return;
+    }
+
+    status.textContent = 'Searching prices for every day, this can take a while...';
+    grid.innerHTML = '';
+
+    const params = new URLSearchParams({origin: origin, destination: destination, month: month});
+    fetch('/api/v1/flights/calendar?' + params.toString())
+        .then(function(resp) {
+            return resp.json().then(function(body) {
+                if (!resp.ok) {
+                    throw new Error(body.message || resp.statusText);
+                }
+                return body;
+            });
+        })
+        .then(function(calendar) {
+            status.textContent = calendar.bestDate
+                ? 'Cheapest day: ' + calendar.bestDate + ' ($' + calendar.minPrice + '). Click a day to search it.'
+                : 'No flights found for this month.';
+            renderPriceCalendar(grid, calendar);
+        })
+        .catch(function(err) {
+            status.textContent = 'Could not load the calendar: ' + err.message;
+        });
+}
+
+function renderPriceCalendar(grid, calendar) {
+    ['Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat', 'Sun'].forEach(function(name) {
+        const header = document.createElement('div');
+        header.className = 'calendar-header';
+        header.textContent = name;
+        grid.appendChild(header);
+    });
+
+    const prices = {};
+    calendar.days.forEach(function(day) { prices[day.date] = day; });
+
+    const first = new Date(calendar.month + '-01T00:00:00Z');
+    const daysInMonth = new Date(Date.UTC(first.getUTCFullYear(), first.getUTCMonth() + 1, 0)).getUTCDate();
+    const offset = (first.getUTCDay() + 6) % 7; // the week starts on monday
+
+    for (let i = 0; i < offset; i++) {
+        grid.appendChild(document.createElement('div'));
+    }
+
+    for (let d = 1; d <= daysInMonth; d++) {
+        const date = calendar.month + '-' + String(d).padStart(2, '0');
+        const day = prices[date];
+        const cell = document.createElement('div');
+        cell.className = 'calendar-day';
+        cell.innerHTML = '<div>' + d + '</div>';
+
+        if (day && day.available) {
+            const range = calendar.maxPrice - calendar.minPrice;
+            const ratio = range > 0 ? (day.price - calendar.minPrice) / range : 0;
+            cell.style.backgroundColor = 'hsl(' + Math.round(120 * (1 - ratio)) + ', 70%, 80%)';
+            cell.classList.add('available');
+            if (date === calendar.bestDate) {
+                cell.classList.add('best');
+            }
+            cell.innerHTML += '<strong>$' + day.price + '</strong>';
+            cell.title = 'Search flights on ' + date;
+            cell.addEventListener('click', function() {
+                document.getElementById('date').value = date;
+                document.getElementById('search-form').submit();
+            });
+        }
+        grid.appendChild(cell);
+    }
+}
+
+// Confirm price: asks the provider for the current price of the offer, the
+// searched one can be stale
+function confirmPrice(event) {
+    const button = event.target;
+    const result = button.nextElementSibling;
+    button.disabled = true;
+    result.textContent = 'Confirming the price...';
+
+    fetch('/api/v1/flights/price', {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json'},
+        body: JSON.stringify({token: button.dataset.token})
+    })
+        .then(function(resp) {
+            return resp.json().then(function(body) {
+                if (!resp.ok) {
+                    throw new Error(body.message || resp.statusText);
+                }
+                return body;
+            });
+        })
+        .then(function(priced) {
+            let text = 'Confirmed: ' + priced.currency + ' ' + priced.price.toFixed(2);
+            if (priced.price_change > 0) {
+                text += ' (up ' + priced.price_change.toFixed(2) + ')';
+            } else if (priced.price_change < 0) {
+                text += ' (down ' + (-priced.price_change).toFixed(2) + ')';
+            } else {
+                text += ' (unchanged)';
+            }
+            (priced.fare_rules || []).forEach(function(rule) {
+                text += ' \u00b7 ' + rule.category.toLowerCase() + ': ' +
+                    (rule.allowed ? (rule.max_penalty ? 'penalty up to ' + rule.max_penalty.toFixed(2) : 'allowed') : 'not allowed');
+            });
+            if (priced.last_ticketing_date) {
+                text += ' \u00b7 book before ' + priced.last_ticketing_date;
+            }
+            result.className = 'confirmed-price small mt-1 ' + (priced.price_change > 0 ? 'text-danger' : 'text-success');
+            result.textContent = text;
+        })
+        .catch(function(err) {
+            result.className = 'confirmed-price small mt-1 text-danger';
+            result.textContent = 'Could not confirm the price: ' + err.message;
+        })
+        .finally(function() {
+            button.disabled = false;
+        });
+}
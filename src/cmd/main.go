@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"log"
 	"net/http"
+	"os"
+	_ "time/tzdata" // the segment times use the time zone of each airport
 
 	"github.com/mariajdab/flight-price/api"
 	"github.com/mariajdab/flight-price/config"
+	"github.com/mariajdab/flight-price/helper"
+	"github.com/mariajdab/flight-price/internal/alerts"
 	"github.com/mariajdab/flight-price/internal/entity"
 	services "github.com/mariajdab/flight-price/internal/flights/service"
+	"github.com/mariajdab/flight-price/internal/history"
+	"github.com/mariajdab/flight-price/internal/jobs"
+	"github.com/mariajdab/flight-price/internal/notify"
+	"github.com/mariajdab/flight-price/internal/providers"
 	"github.com/mariajdab/flight-price/internal/providers/amadeus"
+	"github.com/mariajdab/flight-price/internal/providers/duffel"
+	"github.com/mariajdab/flight-price/internal/providers/fake"
 	"github.com/mariajdab/flight-price/internal/providers/google"
+	"github.com/mariajdab/flight-price/internal/providers/kiwi"
+	"github.com/mariajdab/flight-price/internal/providers/recorder"
 	"github.com/mariajdab/flight-price/internal/providers/sky"
 	"golang.org/x/crypto/acme/autocert"
 )
@@ -23,6 +36,12 @@ func main() {
 		log.Fatal("Failed to load config variables: ", err)
 	}
 
+	if c.AirportTimezonesFile != "" {
+		if err := helper.LoadAirportTimezones(c.AirportTimezonesFile); err != nil {
+			log.Fatalf("Error on airport time zones: %v", err)
+		}
+	}
+
 	tlsConfig := tls.Config{}
 	if c.AppEnv == PROD && c.AppBaseURL != "" {
 		// for production use let's encrypt
@@ -46,6 +65,63 @@ func main() {
 		}
 	}
 
+	flightProviders := newProviders(c)
+	// the rate limits are shared by all the searches, batches and jobs included
+	for i, provider := range flightProviders {
+		flightProviders[i] = providers.NewRateLimited(provider, c.ProviderRequestsPerSecond)
+	}
+	flightService := services.NewFlightService(flightProviders...)
+	flightService.SetCacheTTL(c.SearchCacheTTL)
+	flightService.SetOfferTTL(c.OfferTTL)
+	flightService.SetDateConcurrency(c.DateConcurrency)
+
+	priceHistory, err := history.NewSQLiteRepository(c.PriceHistoryDBPath)
+	if err != nil {
+		log.Fatalf("Error on price history: %v", err)
+	}
+	defer priceHistory.Close()
+	flightService.SetPriceStore(priceHistory)
+
+	notifier, err := newNotifier(c)
+	if err != nil {
+		log.Fatalf("Error on notification channels: %v", err)
+	}
+	flightService.SetOutageNotifier(notifier, c.ProviderOutageFailures)
+
+	savedSearches, err := alerts.NewSQLiteRepository(priceHistory.DB())
+	if err != nil {
+		log.Fatalf("Error on saved searches: %v", err)
+	}
+	scheduler := alerts.NewScheduler(savedSearches, flightService, alerts.NewChannelNotifier(notifier), c.AlertsCheckInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	searchJobs := jobs.NewManager(flightService, c.SearchJobWorkers, c.SearchJobTTL)
+	searchJobs.Start(ctx)
+
+	server := api.New(flightService, &tlsConfig,
+		api.WithSavedSearches(savedSearches),
+		api.WithPriceHistory(priceHistory),
+		api.WithSearchJobs(searchJobs),
+		api.WithBatchConcurrency(c.BatchConcurrency),
+		api.WithAssetsDir(c.DevAssetsDir),
+	)
+
+	if err := server.Start(); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
+
+// newProviders builds the providers of the configured mode, record and replay
+// use the real clients with a transport that saves or reads their responses
+func newProviders(c *config.Config) []providers.Flight {
+	if c.ProviderMode == "fake" {
+		log.Println("using the fake provider, the flights are not real")
+		return []providers.Flight{fake.New("", c.FakeFixturesDir)}
+	}
+
 	cfg := entity.ProvConfig{
 		Providers: []entity.Provider{
 			{
@@ -71,6 +147,10 @@ func main() {
 	}
 
 	httpClient := http.Client{}
+	if c.ProviderMode == string(recorder.ModeRecord) || c.ProviderMode == string(recorder.ModeReplay) {
+		log.Printf("provider responses in %s mode, directory %s", c.ProviderMode, c.ProviderRecordingsDir)
+		httpClient.Transport = recorder.NewTransport(recorder.Mode(c.ProviderMode), c.ProviderRecordingsDir, nil)
+	}
 
 	amadeusClient := amadeus.NewClient(httpClient, cfg.Providers[0])
 	amadeusAdapter := amadeus.NewAdapterAmadeus(amadeusClient)
@@ -81,15 +161,60 @@ func main() {
 	googleClient := google.NewClient(httpClient, cfg.Providers[2])
 	googleAdapter := google.NewAdapterGoogleFlight(googleClient)
 
-	flightService := services.NewFlightService(
-		amadeusAdapter,
-		skyAdapter,
-		googleAdapter,
-	)
+	flightProviders := []providers.Flight{amadeusAdapter, skyAdapter, googleAdapter}
 
-	server := api.New(flightService, &tlsConfig)
+	if c.KiwiEnabled {
+		kiwiClient := kiwi.NewClient(httpClient, entity.Provider{
+			Name:    entity.KiwiProvider,
+			BaseURL: c.KiwiBaseURL,
+			Apikey:  c.KiwiAPIKey,
+			Timeout: c.ClientTimeout,
+		})
+		flightProviders = append(flightProviders, kiwi.NewAdapterKiwi(kiwiClient))
+	}
 
-	if err := server.Start(); err != nil {
-		log.Fatalf("Server error: %v", err)
+	if c.DuffelEnabled {
+		duffelClient := duffel.NewClient(httpClient, entity.Provider{
+			Name:    entity.DuffelProvider,
+			BaseURL: c.DuffelBaseURL,
+			Apikey:  c.DuffelAPIKey,
+			Timeout: c.ClientTimeout,
+		})
+		flightProviders = append(flightProviders, duffel.NewAdapterDuffel(duffelClient))
+	}
+
+	return flightProviders
+}
+
+// newNotifier builds the configured notification channels, each one retries on
+// its own so a failing channel doesn't send the message twice to the others
+func newNotifier(c *config.Config) (notify.Notifier, error) {
+	deadLetterFile, err := os.OpenFile(c.NotifyDeadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	deadLetter := notify.NewDeadLetterLog(deadLetterFile)
+
+	httpClient := &http.Client{Timeout: c.ClientTimeout}
+
+	var channels []notify.Notifier
+	if c.SMTPAddr != "" {
+		channels = append(channels, notify.NewSMTP(c.SMTPAddr, c.SMTPFrom, c.SMTPTo, c.SMTPUsername, c.SMTPPassword))
+	}
+	if c.WebhookURL != "" {
+		channels = append(channels, notify.NewWebhook(c.WebhookURL, c.WebhookSecret, httpClient))
+	}
+	if c.SlackWebhookURL != "" {
+		channels = append(channels, notify.NewSlack(c.SlackWebhookURL, httpClient))
+	}
+	if len(channels) == 0 {
+		log.Println("no notification channel configured, the notifications are only logged")
+		return notify.Log{}, nil
+	}
+
+	multi := make(notify.Multi, 0, len(channels))
+	for _, channel := range channels {
+		multi = append(multi, notify.WithRetry(channel, c.NotifyAttempts, notify.DefaultBackoff, deadLetter))
 	}
+	return multi, nil
 }
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -15,20 +17,69 @@ const dockerSecretPathPrefix = "/run/secrets"
 type Config struct {
 	ServerPort string `validate:"required,len=4"`
 
-	AmadeusAPIKey    string `validate:"required,min=25"`
-	AmadeusAPISecret string `validate:"required,min=10"`
-	AmadeusBaseURL   string `validate:"required,min=15"`
+	// ProviderMode is live, record (live saving the responses), replay (the saved
+	// responses, no network) or fake (generated flights), the keys are only needed
+	// to call the providers
+	ProviderMode          string `validate:"oneof=live record replay fake"`
+	ProviderRecordingsDir string `validate:"required_if=ProviderMode record,required_if=ProviderMode replay"`
+	FakeFixturesDir       string
 
-	SkyRapidAPIKey  string `validate:"required,min=25"`
-	SkyRapidBaseURL string `validate:"required,min=15"`
+	AmadeusAPIKey    string `validate:"required_if=ProviderMode live,required_if=ProviderMode record,omitempty,min=25"`
+	AmadeusAPISecret string `validate:"required_if=ProviderMode live,required_if=ProviderMode record,omitempty,min=10"`
+	AmadeusBaseURL   string `validate:"required_unless=ProviderMode fake,omitempty,min=15"`
 
-	GoogleFlightRapidAPIKey  string `validate:"required,min=25"`
-	GoogleFlightRapidBaseURL string `validate:"required,min=15"`
+	SkyRapidAPIKey  string `validate:"required_if=ProviderMode live,required_if=ProviderMode record,omitempty,min=25"`
+	SkyRapidBaseURL string `validate:"required_unless=ProviderMode fake,omitempty,min=15"`
+
+	GoogleFlightRapidAPIKey  string `validate:"required_if=ProviderMode live,required_if=ProviderMode record,omitempty,min=25"`
+	GoogleFlightRapidBaseURL string `validate:"required_unless=ProviderMode fake,omitempty,min=15"`
+
+	// the kiwi provider is optional, it is enabled when its key is configured
+	KiwiEnabled bool
+	KiwiAPIKey  string `validate:"omitempty,min=25"`
+	KiwiBaseURL string `validate:"required_if=KiwiEnabled true,omitempty,url"`
+
+	// the duffel provider is optional too, its offer ids can be re-priced or booked
+	DuffelEnabled bool
+	DuffelAPIKey  string `validate:"omitempty,min=25"`
+	DuffelBaseURL string `validate:"required_if=DuffelEnabled true,omitempty,url"`
 
 	AppBaseURL string `validate:"required,url"`
 	AppEnv     string `validate:"required,min=5"`
+	// DevAssetsDir reads the templates and the static files from disk, empty uses the embedded ones
+	DevAssetsDir string
 
 	ClientTimeout time.Duration `validate:"required"`
+
+	// AirportTimezonesFile adds airports to the embedded time zones dataset, same iata,timezone columns
+	AirportTimezonesFile string
+
+	SearchCacheTTL  time.Duration `validate:"gte=0"`
+	OfferTTL        time.Duration `validate:"gte=0"`
+	DateConcurrency int           `validate:"gte=1,lte=10"`
+
+	AlertsCheckInterval time.Duration `validate:"gte=1m"`
+
+	// notification channels, the ones without address are disabled
+	SMTPAddr               string   `validate:"omitempty,hostname_port"`
+	SMTPFrom               string   `validate:"required_with=SMTPAddr,omitempty,email"`
+	SMTPTo                 []string `validate:"dive,email"`
+	SMTPUsername           string
+	SMTPPassword           string
+	WebhookURL             string `validate:"omitempty,url"`
+	WebhookSecret          string
+	SlackWebhookURL        string `validate:"omitempty,url"`
+	NotifyAttempts         int    `validate:"gte=1,lte=10"`
+	NotifyDeadLetterPath   string `validate:"required"`
+	ProviderOutageFailures int    `validate:"gte=1"`
+
+	PriceHistoryDBPath string `validate:"required"`
+
+	SearchJobWorkers int           `validate:"gte=1,lte=50"`
+	SearchJobTTL     time.Duration `validate:"gte=1m"`
+
+	BatchConcurrency          int     `validate:"gte=1,lte=20"`
+	ProviderRequestsPerSecond float64 `validate:"gte=0"`
 }
 
 func Load() (*Config, error) {
@@ -40,47 +91,136 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	amadeusAPIKey, err := os.ReadFile(filepath.Join(
-		dockerSecretPathPrefix,
-		getEnvOrFail("AMADEUS_API_KEY"),
-	))
+	searchCacheTTL, err := time.ParseDuration(getEnvOrDefault("SEARCH_CACHE_TTL", "5m"))
+	if err != nil {
+		return nil, err
+	}
+
+	offerTTL, err := time.ParseDuration(getEnvOrDefault("PROVIDER_OFFERS_TTL", "30m"))
+	if err != nil {
+		return nil, err
+	}
+
+	dateConcurrency, err := strconv.Atoi(getEnvOrDefault("FLEXIBLE_SEARCH_CONCURRENCY", "3"))
+	if err != nil {
+		return nil, err
+	}
+
+	alertsCheckInterval, err := time.ParseDuration(getEnvOrDefault("ALERTS_CHECK_INTERVAL", "1h"))
+	if err != nil {
+		return nil, err
+	}
+
+	notifyAttempts, err := strconv.Atoi(getEnvOrDefault("NOTIFY_ATTEMPTS", "3"))
+	if err != nil {
+		return nil, err
+	}
+
+	providerOutageFailures, err := strconv.Atoi(getEnvOrDefault("PROVIDER_OUTAGE_FAILURES", "3"))
+	if err != nil {
+		return nil, err
+	}
+
+	searchJobWorkers, err := strconv.Atoi(getEnvOrDefault("SEARCH_JOB_WORKERS", "4"))
 	if err != nil {
 		return nil, err
 	}
-	amadeusAPISecret, err := os.ReadFile(filepath.Join(
-		dockerSecretPathPrefix,
-		getEnvOrFail("AMADEUS_API_SECRET"),
-	))
+
+	searchJobTTL, err := time.ParseDuration(getEnvOrDefault("SEARCH_JOB_TTL", "30m"))
 	if err != nil {
 		return nil, err
 	}
-	skyRapidAPIKey, err := os.ReadFile(filepath.Join(
-		dockerSecretPathPrefix,
-		getEnvOrFail("SKY_RAPID_API_KEY"),
-	))
+
+	batchConcurrency, err := strconv.Atoi(getEnvOrDefault("BATCH_SEARCH_CONCURRENCY", "4"))
 	if err != nil {
 		return nil, err
 	}
-	googleFlightAPIKey, err := os.ReadFile(filepath.Join(
-		dockerSecretPathPrefix,
-		getEnvOrFail("GOOGLE_FLIGHT_RAPID_API_KEY"),
-	))
+
+	providerRequestsPerSecond, err := strconv.ParseFloat(getEnvOrDefault("PROVIDER_REQUESTS_PER_SECOND", "5"), 64)
 	if err != nil {
 		return nil, err
 	}
 
+	// the replayed and fake providers don't call the APIs, they run without keys
+	providerMode := getEnvOrDefault("PROVIDER_MODE", "live")
+	needsKeys := providerMode == "live" || providerMode == "record"
+	needsURLs := providerMode != "fake"
+
+	var amadeusAPIKey, amadeusAPISecret, skyRapidAPIKey, googleFlightAPIKey string
+	if needsKeys {
+		if amadeusAPIKey, err = readSecret("AMADEUS_API_KEY"); err != nil {
+			return nil, err
+		}
+		if amadeusAPISecret, err = readSecret("AMADEUS_API_SECRET"); err != nil {
+			return nil, err
+		}
+		if skyRapidAPIKey, err = readSecret("SKY_RAPID_API_KEY"); err != nil {
+			return nil, err
+		}
+		if googleFlightAPIKey, err = readSecret("GOOGLE_FLIGHT_RAPID_API_KEY"); err != nil {
+			return nil, err
+		}
+	}
+
+	_, kiwiEnabled := os.LookupEnv("KIWI_API_KEY")
+	var kiwiAPIKey string
+	if kiwiEnabled && needsKeys {
+		if kiwiAPIKey, err = readSecret("KIWI_API_KEY"); err != nil {
+			return nil, err
+		}
+	}
+
+	_, duffelEnabled := os.LookupEnv("DUFFEL_API_KEY")
+	var duffelAPIKey string
+	if duffelEnabled && needsKeys {
+		if duffelAPIKey, err = readSecret("DUFFEL_API_KEY"); err != nil {
+			return nil, err
+		}
+	}
+
 	c := Config{
-		AppEnv:                   getEnvOrFail("APP_ENV"),
-		ServerPort:               getEnvOrFail("SERVER_PORT"),
-		AppBaseURL:               getEnvOrFail("APP_BASE_URL"),
-		AmadeusBaseURL:           getEnvOrFail("AMADEUS_BASE_URL"),
-		SkyRapidBaseURL:          getEnvOrFail("SKY_RAPID_BASE_URL"),
-		GoogleFlightRapidBaseURL: getEnvOrFail("GOOGLE_FLIGHT_RAPID_BASE_URL"),
-		AmadeusAPIKey:            string(amadeusAPIKey),
-		AmadeusAPISecret:         string(amadeusAPISecret),
-		SkyRapidAPIKey:           string(skyRapidAPIKey),
-		GoogleFlightRapidAPIKey:  string(googleFlightAPIKey),
-		ClientTimeout:            clientTimeout,
+		AppEnv:                    getEnvOrFail("APP_ENV"),
+		ServerPort:                getEnvOrFail("SERVER_PORT"),
+		AppBaseURL:                getEnvOrFail("APP_BASE_URL"),
+		DevAssetsDir:              getEnvOrDefault("DEV_ASSETS_DIR", ""),
+		ProviderMode:              providerMode,
+		ProviderRecordingsDir:     getEnvOrDefault("PROVIDER_RECORDINGS_DIR", "recordings"),
+		FakeFixturesDir:           getEnvOrDefault("FAKE_PROVIDER_FIXTURES_DIR", ""),
+		AmadeusBaseURL:            providerEnv("AMADEUS_BASE_URL", needsURLs),
+		SkyRapidBaseURL:           providerEnv("SKY_RAPID_BASE_URL", needsURLs),
+		GoogleFlightRapidBaseURL:  providerEnv("GOOGLE_FLIGHT_RAPID_BASE_URL", needsURLs),
+		AmadeusAPIKey:             amadeusAPIKey,
+		AmadeusAPISecret:          amadeusAPISecret,
+		SkyRapidAPIKey:            skyRapidAPIKey,
+		GoogleFlightRapidAPIKey:   googleFlightAPIKey,
+		KiwiEnabled:               kiwiEnabled && providerMode != "fake",
+		KiwiAPIKey:                kiwiAPIKey,
+		KiwiBaseURL:               getEnvOrDefault("KIWI_BASE_URL", "https://api.tequila.kiwi.com"),
+		DuffelEnabled:             duffelEnabled && providerMode != "fake",
+		DuffelAPIKey:              duffelAPIKey,
+		DuffelBaseURL:             getEnvOrDefault("DUFFEL_BASE_URL", "https://api.duffel.com"),
+		ClientTimeout:             clientTimeout,
+		AirportTimezonesFile:      getEnvOrDefault("AIRPORT_TIMEZONES_FILE", ""),
+		SearchCacheTTL:            searchCacheTTL,
+		OfferTTL:                  offerTTL,
+		DateConcurrency:           dateConcurrency,
+		AlertsCheckInterval:       alertsCheckInterval,
+		SMTPAddr:                  getEnvOrDefault("NOTIFY_SMTP_ADDR", ""),
+		SMTPFrom:                  getEnvOrDefault("NOTIFY_SMTP_FROM", ""),
+		SMTPTo:                    splitList(getEnvOrDefault("NOTIFY_SMTP_TO", "")),
+		SMTPUsername:              getEnvOrDefault("NOTIFY_SMTP_USERNAME", ""),
+		SMTPPassword:              getEnvOrDefault("NOTIFY_SMTP_PASSWORD", ""),
+		WebhookURL:                getEnvOrDefault("NOTIFY_WEBHOOK_URL", ""),
+		WebhookSecret:             getEnvOrDefault("NOTIFY_WEBHOOK_SECRET", ""),
+		SlackWebhookURL:           getEnvOrDefault("NOTIFY_SLACK_WEBHOOK_URL", ""),
+		NotifyAttempts:            notifyAttempts,
+		NotifyDeadLetterPath:      getEnvOrDefault("NOTIFY_DEAD_LETTER_PATH", "notifications-dead-letter.log"),
+		ProviderOutageFailures:    providerOutageFailures,
+		PriceHistoryDBPath:        getEnvOrDefault("PRICE_HISTORY_DB_PATH", "flight-price.db"),
+		SearchJobWorkers:          searchJobWorkers,
+		SearchJobTTL:              searchJobTTL,
+		BatchConcurrency:          batchConcurrency,
+		ProviderRequestsPerSecond: providerRequestsPerSecond,
 	}
 	if err := validate(c); err != nil {
 		return nil, err
@@ -96,6 +236,41 @@ func getEnvOrFail(key string) string {
 	}
 }
 
+func getEnvOrDefault(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
+
+// providerEnv fails when the env is missing and the providers are called
+func providerEnv(key string, required bool) string {
+	if required {
+		return getEnvOrFail(key)
+	}
+	return getEnvOrDefault(key, "")
+}
+
+// readSecret reads the docker secret named in the env
+func readSecret(key string) (string, error) {
+	secret, err := os.ReadFile(filepath.Join(dockerSecretPathPrefix, getEnvOrFail(key)))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// splitList splits a comma separated value ignoring the empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func validate(config Config) error {
 	validate := validator.New()
 
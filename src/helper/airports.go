@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// airport_timezones.csv maps IATA airport (and city) codes to IANA time zones
+//
+//go:embed data/airport_timezones.csv
+var airportTimezonesCSV []byte
+
+// ErrUnknownTimeZone is returned for the airports missing in the dataset
+var ErrUnknownTimeZone = errors.New("time zone was not found for the airport")
+
+var (
+	airportTimezonesOnce sync.Once
+	airportTimezonesMu   sync.RWMutex
+	airportTimezones     map[string]*time.Location
+
+	// missingAirports logs only once each airport without time zone
+	missingAirports sync.Map
+)
+
+func loadAirportTimezones() {
+	timezones, err := readAirportTimezones(bytes.NewReader(airportTimezonesCSV))
+	if err != nil {
+		log.Printf("error reading airport time zones dataset: %v", err)
+	}
+
+	airportTimezonesMu.Lock()
+	defer airportTimezonesMu.Unlock()
+	airportTimezones = timezones
+}
+
+// readAirportTimezones reads a CSV with the columns iata,timezone and a header,
+// the rows with an unknown time zone are skipped
+func readAirportTimezones(r io.Reader) (map[string]*time.Location, error) {
+	timezones := make(map[string]*time.Location)
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return timezones, err
+	}
+	if len(records) == 0 {
+		return timezones, nil
+	}
+
+	// skip the header
+	for _, r := range records[1:] {
+		if len(r) < 2 {
+			continue
+		}
+		loc, err := time.LoadLocation(r[1])
+		if err != nil {
+			log.Printf("warning: unknown time zone %s for airport %s: %v", r[1], r[0], err)
+			continue
+		}
+		timezones[strings.ToUpper(strings.TrimSpace(r[0]))] = loc
+	}
+	return timezones, nil
+}
+
+// LoadAirportTimezones adds the airports of a CSV file with the same columns
+// as the embedded dataset, iata,timezone, e.g. a complete dataset exported from
+// OurAirports. The airports of the file replace the embedded ones
+func LoadAirportTimezones(path string) error {
+	airportTimezonesOnce.Do(loadAirportTimezones)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	timezones, err := readAirportTimezones(f)
+	if err != nil {
+		return fmt.Errorf("error reading airport time zones %s: %w", path, err)
+	}
+
+	airportTimezonesMu.Lock()
+	defer airportTimezonesMu.Unlock()
+	for code, loc := range timezones {
+		airportTimezones[code] = loc
+	}
+	return nil
+}
+
+// AirportCode prefers the IATA code of the airport, the name is used only when
+// the provider doesn't send the code
+func AirportCode(code, name string) string {
+	if code != "" {
+		return code
+	}
+	return name
+}
+
+// AirportLocation returns the time zone of the airport
+func AirportLocation(code string) (*time.Location, error) {
+	airportTimezonesOnce.Do(loadAirportTimezones)
+
+	airportTimezonesMu.RLock()
+	loc, exists := airportTimezones[strings.ToUpper(strings.TrimSpace(code))]
+	airportTimezonesMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTimeZone, code)
+	}
+	return loc, nil
+}
+
+// ParseAirportTime parses a local time of the airport and returns it with the
+// offset of the airport time zone. An airport missing in the dataset doesn't
+// drop the flight: zoneKnown is false and the time is the local clock of the
+// airport written in UTC, it is not the real instant and the segment must be
+// marked with TimeZoneUnknown
+func ParseAirportTime(layout, value, airport string) (t time.Time, zoneKnown bool, err error) {
+	loc, err := AirportLocation(airport)
+	zoneKnown = err == nil
+	if !zoneKnown {
+		if _, logged := missingAirports.LoadOrStore(airport, true); !logged {
+			log.Printf("warning: %v, its segments are marked with an unknown time zone", err)
+		}
+		loc = time.UTC
+	}
+
+	t, err = time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time %q for the airport %s: %w", value, airport, err)
+	}
+	return t, zoneKnown, nil
+}
@@ -0,0 +1,41 @@
+package helper
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+// CommonBaggage returns the allowance valid in every segment of the flight,
+// the smallest one. It is nil when a segment has an unknown allowance
+func CommonBaggage(allowances []*entity.Baggage) *entity.Baggage {
+	var common *entity.Baggage
+	for _, b := range allowances {
+		if b == nil {
+			return nil
+		}
+		if common == nil || b.Quantity < common.Quantity ||
+			(b.Quantity == common.Quantity && b.Weight < common.Weight) {
+			common = b
+		}
+	}
+	if common == nil {
+		return nil
+	}
+
+	c := *common
+	return &c
+}
+
+// FareBrand joins the distinct fare brands of the segments, most flights have only one
+func FareBrand(brands []string) string {
+	distinct := make([]string, 0, 1)
+	for _, brand := range brands {
+		brand = strings.TrimSpace(brand)
+		if brand != "" && !slices.Contains(distinct, brand) {
+			distinct = append(distinct, brand)
+		}
+	}
+	return strings.Join(distinct, " / ")
+}
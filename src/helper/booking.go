@@ -0,0 +1,13 @@
+package helper
+
+import "net/url"
+
+// BookingURL returns the link when it is an absolute http or https url, the
+// links of the providers end in the pages and the exports
+func BookingURL(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
@@ -89,3 +89,31 @@ func CityToSkyCode(cityName string) string {
 	}
 	return code
 }
+
+func CityToKiwiCode(cityName string) string {
+	cityToKiwiCode := map[string]string{
+		"paris":     "PAR",
+		"madrid":    "MAD",
+		"new york":  "NYC",
+		"london":    "LON",
+		"tokyo":     "TYO",
+		"berlin":    "BER",
+		"rome":      "ROM",
+		"moscow":    "MOW",
+		"dubai":     "DXB",
+		"barcelona": "BCN",
+		"lisbon":    "LIS",
+		"amsterdam": "AMS",
+		"frankfurt": "FRA",
+		"munich":    "MUC",
+	}
+
+	normalizedCity := strings.ToLower(strings.TrimSpace(cityName))
+
+	code, exists := cityToKiwiCode[normalizedCity]
+	if !exists {
+		log.Println(fmt.Errorf("kiwi code was not found for the city: %s", cityName))
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+// LayoverMinutes returns the waiting time between consecutive segments. Both
+// times are in the connecting airport, so without its time zone the local
+// clocks are compared instead of the instants
+func LayoverMinutes(segments []entity.Segment) []int {
+	if len(segments) < 2 {
+		return nil
+	}
+
+	layovers := make([]int, 0, len(segments)-1)
+	for i := 1; i < len(segments); i++ {
+		arrival, departure := segments[i-1].ArrivalTime, segments[i].DepartureTime
+		if segments[i-1].TimeZoneUnknown || segments[i].TimeZoneUnknown {
+			arrival, departure = LocalClock(arrival), LocalClock(departure)
+		}
+		layovers = append(layovers, int(departure.Sub(arrival).Minutes()))
+	}
+	return layovers
+}
+
+// LocalClock returns the same date and clock in UTC, dropping the time zone
+func LocalClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
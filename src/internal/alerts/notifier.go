@@ -0,0 +1,43 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/notify"
+)
+
+// Notifier sends the price alerts to the user
+type Notifier interface {
+	NotifyPriceDrop(ctx context.Context, alert entity.PriceAlert) error
+}
+
+// ChannelNotifier sends the price alerts through the notification channels
+type ChannelNotifier struct {
+	channel notify.Notifier
+}
+
+func NewChannelNotifier(channel notify.Notifier) *ChannelNotifier {
+	return &ChannelNotifier{channel: channel}
+}
+
+func (n *ChannelNotifier) NotifyPriceDrop(ctx context.Context, alert entity.PriceAlert) error {
+	s := alert.SavedSearch
+	text := fmt.Sprintf("%s - %s on %s is now $%.2f with %s, below your alert of $%.2f.",
+		s.Search.Origin, s.Search.Destination, s.Search.DateDeparture,
+		alert.Flight.Price, alert.Flight.ProviderName, s.PriceThreshold)
+	if alert.PreviousPrice > 0 {
+		text += fmt.Sprintf(" The previous price was $%.2f.", alert.PreviousPrice)
+	}
+
+	return n.channel.Send(ctx, notify.Message{
+		Event:     notify.EventPriceDrop,
+		Subject:   fmt.Sprintf("Price drop: %s - %s $%.2f", s.Search.Origin, s.Search.Destination, alert.Flight.Price),
+		Text:      text,
+		Recipient: s.Email,
+		Data:      alert,
+		CreatedAt: time.Now(),
+	})
+}
@@ -0,0 +1,146 @@
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+var ErrNotFound = errors.New("saved search not found")
+
+// Repository stores the saved searches and the prices observed for them
+type Repository interface {
+	SaveSearch(ctx context.Context, search entity.SavedSearch) (entity.SavedSearch, error)
+	UpdateSearch(ctx context.Context, search entity.SavedSearch) error
+	GetSearch(ctx context.Context, userID, id string) (entity.SavedSearch, error)
+	ListSearches(ctx context.Context, userID string) ([]entity.SavedSearch, error)
+	AllSearches(ctx context.Context) ([]entity.SavedSearch, error)
+	DeleteSearch(ctx context.Context, userID, id string) error
+
+	AddObservation(ctx context.Context, observation entity.PriceObservation) error
+	Observations(ctx context.Context, savedSearchID string) ([]entity.PriceObservation, error)
+}
+
+// InMemoryRepository keeps the data while the server is running
+type InMemoryRepository struct {
+	mu           sync.RWMutex
+	searches     map[string]entity.SavedSearch
+	observations map[string][]entity.PriceObservation
+}
+
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		searches:     make(map[string]entity.SavedSearch),
+		observations: make(map[string][]entity.PriceObservation),
+	}
+}
+
+func (r *InMemoryRepository) SaveSearch(_ context.Context, search entity.SavedSearch) (entity.SavedSearch, error) {
+	id, err := newID()
+	if err != nil {
+		return entity.SavedSearch{}, err
+	}
+	search.ID = id
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.searches[id] = search
+	return search, nil
+}
+
+func (r *InMemoryRepository) UpdateSearch(_ context.Context, search entity.SavedSearch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.searches[search.ID]; !exists {
+		return ErrNotFound
+	}
+	r.searches[search.ID] = search
+	return nil
+}
+
+func (r *InMemoryRepository) GetSearch(_ context.Context, userID, id string) (entity.SavedSearch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	search, exists := r.searches[id]
+	if !exists || search.UserID != userID {
+		return entity.SavedSearch{}, ErrNotFound
+	}
+	return search, nil
+}
+
+func (r *InMemoryRepository) ListSearches(_ context.Context, userID string) ([]entity.SavedSearch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	searches := make([]entity.SavedSearch, 0)
+	for _, s := range r.searches {
+		if s.UserID == userID {
+			searches = append(searches, s)
+		}
+	}
+	sortByCreation(searches)
+	return searches, nil
+}
+
+func (r *InMemoryRepository) AllSearches(_ context.Context) ([]entity.SavedSearch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	searches := make([]entity.SavedSearch, 0, len(r.searches))
+	for _, s := range r.searches {
+		searches = append(searches, s)
+	}
+	sortByCreation(searches)
+	return searches, nil
+}
+
+func (r *InMemoryRepository) DeleteSearch(_ context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	search, exists := r.searches[id]
+	if !exists || search.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.searches, id)
+	delete(r.observations, id)
+	return nil
+}
+
+func (r *InMemoryRepository) AddObservation(_ context.Context, observation entity.PriceObservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observations[observation.SavedSearchID] = append(r.observations[observation.SavedSearchID], observation)
+	return nil
+}
+
+func (r *InMemoryRepository) Observations(_ context.Context, savedSearchID string) ([]entity.PriceObservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	observations := make([]entity.PriceObservation, len(r.observations[savedSearchID]))
+	copy(observations, r.observations[savedSearchID])
+	return observations, nil
+}
+
+func sortByCreation(searches []entity.SavedSearch) {
+	sort.Slice(searches, func(i, j int) bool {
+		return searches[i].CreatedAt.Before(searches[j].CreatedAt)
+	})
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/flights/ranking"
+	services "github.com/mariajdab/flight-price/internal/flights/service"
+)
+
+const (
+	DefaultCheckInterval = time.Hour
+
+	dateLayout = "2006-01-02"
+)
+
+// Searcher runs a flight search, it is implemented by services.FlightService
+type Searcher interface {
+	SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) entity.FlightPriceResponse
+}
+
+// Scheduler re-runs the saved searches on an interval, stores the price found
+// and notifies when it drops below the threshold of the search
+type Scheduler struct {
+	repo     Repository
+	searcher Searcher
+	notifier Notifier
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewScheduler(repo Repository, searcher Searcher, notifier Notifier, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	return &Scheduler{
+		repo:     repo,
+		searcher: searcher,
+		notifier: notifier,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run checks the saved searches every interval until the context is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	log.Printf("price alerts scheduler started, checking every %s", s.interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("price alerts scheduler stopped")
+			return
+		case <-ticker.C:
+			s.CheckAll(ctx)
+		}
+	}
+}
+
+// CheckAll runs every saved search that has not departed once, the errors are
+// logged so one failing search does not stop the others. The searches of an
+// expired user are deleted, nobody can see their alerts anymore
+func (s *Scheduler) CheckAll(ctx context.Context) {
+	searches, err := s.repo.AllSearches(ctx)
+	if err != nil {
+		log.Printf("error loading saved searches: %v", err)
+		return
+	}
+
+	for _, saved := range searches {
+		if ctx.Err() != nil {
+			return
+		}
+		if expired(saved, s.now()) {
+			if err := s.repo.DeleteSearch(ctx, saved.UserID, saved.ID); err != nil {
+				log.Printf("error deleting expired saved search %s: %v", saved.ID, err)
+			}
+			continue
+		}
+		if departed(saved, s.now()) {
+			continue
+		}
+		if err := s.Check(ctx, saved); err != nil {
+			log.Printf("error checking saved search %s: %v", saved.ID, err)
+		}
+	}
+}
+
+// Check runs the saved search and records the cheapest price that matches its filters
+func (s *Scheduler) Check(ctx context.Context, saved entity.SavedSearch) error {
+	resp := s.searcher.SearchFlights(ctx, saved.Search)
+	if len(resp.FlightByProvider) == 0 {
+		return fmt.Errorf("no provider answered for %s - %s", saved.Search.Origin, saved.Search.Destination)
+	}
+
+	resp, err := services.ApplyQuery(resp, saved.Query)
+	if err != nil {
+		return fmt.Errorf("invalid filters: %w", err)
+	}
+
+	cheapest := resp.Rankings[ranking.Cheapest]
+	if len(cheapest) == 0 {
+		return nil // no flight matches the filters
+	}
+	flight := cheapest[0]
+	now := s.now()
+
+	if err := s.repo.AddObservation(ctx, entity.PriceObservation{
+		SavedSearchID: saved.ID,
+		Price:         flight.Price,
+		Provider:      flight.ProviderName,
+		ObservedAt:    now,
+	}); err != nil {
+		return fmt.Errorf("error storing price: %w", err)
+	}
+
+	previousPrice := saved.LastPrice
+	saved.LastCheckedAt = now
+	saved.LastPrice = flight.Price
+
+	// notify only once for each new lower price below the threshold
+	if flight.Price < saved.PriceThreshold &&
+		(saved.LastNotifiedPrice == 0 || flight.Price < saved.LastNotifiedPrice) {
+		err := s.notifier.NotifyPriceDrop(ctx, entity.PriceAlert{
+			SavedSearch:   saved,
+			Flight:        flight,
+			PreviousPrice: previousPrice,
+		})
+		if err != nil {
+			log.Printf("error sending price alert for saved search %s: %v", saved.ID, err)
+		} else {
+			saved.LastNotifiedPrice = flight.Price
+		}
+	}
+
+	return s.repo.UpdateSearch(ctx, saved)
+}
+
+// departed reports if the departure day of the search is over, its flights
+// can't be booked anymore. The saved search is kept for its price history
+func departed(saved entity.SavedSearch, now time.Time) bool {
+	date, err := time.Parse(dateLayout, saved.Search.DateDeparture)
+	if err != nil {
+		return false
+	}
+	return !now.Before(date.AddDate(0, 0, 1))
+}
+
+// expired reports if the token of the user that saved the search has expired,
+// a search without expiration was saved before it was stored and its user is unknown
+func expired(saved entity.SavedSearch, now time.Time) bool {
+	return saved.ExpiresAt.IsZero() || !now.Before(saved.ExpiresAt)
+}
@@ -0,0 +1,245 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/notify"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type priceSearcher struct {
+	prices []float64
+	calls  int
+}
+
+func (s *priceSearcher) SearchFlights(_ context.Context, _ entity.FlightSearchParam) entity.FlightPriceResponse {
+	price := s.prices[s.calls]
+	s.calls++
+
+	flights := []entity.Flight{
+		{ProviderName: "a", Price: price, DurationMinutes: 120},
+		{ProviderName: "b", Price: price + 50, DurationMinutes: 90, Stops: 1},
+	}
+	return entity.FlightPriceResponse{
+		Flights:          flights,
+		FlightByProvider: []entity.FlightSearchResponse{{Provider: "a", Flights: flights}},
+	}
+}
+
+type recordingNotifier struct {
+	alerts []entity.PriceAlert
+}
+
+func (n *recordingNotifier) NotifyPriceDrop(_ context.Context, alert entity.PriceAlert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func saveTestSearch(t *testing.T, repo Repository, query entity.FlightQuery) entity.SavedSearch {
+	saved, err := repo.SaveSearch(context.Background(), entity.SavedSearch{
+		UserID:         "maria",
+		Search:         entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-06-01"},
+		Query:          query,
+		PriceThreshold: 200,
+		Email:          "maria@example.com",
+		CreatedAt:      time.Now(),
+		ExpiresAt:      time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	return saved
+}
+
+func TestSchedulerCheck_NotifiesEachNewLowerPrice(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepository()
+	searcher := &priceSearcher{prices: []float64{250, 180, 190, 150}}
+	notifier := &recordingNotifier{}
+	scheduler := NewScheduler(repo, searcher, notifier, time.Minute)
+
+	saved := saveTestSearch(t, repo, entity.DefaultFlightQuery())
+	for range searcher.prices {
+		scheduler.CheckAll(ctx)
+	}
+
+	require.Len(t, notifier.alerts, 2)
+	assert.Equal(t, 180.0, notifier.alerts[0].Flight.Price)
+	assert.Equal(t, 250.0, notifier.alerts[0].PreviousPrice)
+	assert.Equal(t, 150.0, notifier.alerts[1].Flight.Price)
+
+	observations, err := repo.Observations(ctx, saved.ID)
+	require.NoError(t, err)
+	require.Len(t, observations, 4)
+	assert.Equal(t, "a", observations[0].Provider)
+
+	saved, err = repo.GetSearch(ctx, "maria", saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, saved.LastPrice)
+	assert.Equal(t, 150.0, saved.LastNotifiedPrice)
+	assert.False(t, saved.LastCheckedAt.IsZero())
+}
+
+func TestSchedulerCheck_UsesTheFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepository()
+	notifier := &recordingNotifier{}
+	scheduler := NewScheduler(repo, &priceSearcher{prices: []float64{100}}, notifier, time.Minute)
+
+	query := entity.DefaultFlightQuery()
+	query.MaxDurationMinutes = 100
+	saved := saveTestSearch(t, repo, query)
+
+	require.NoError(t, scheduler.Check(ctx, saved))
+
+	// only the flight of provider b is short enough and it costs 150
+	require.Len(t, notifier.alerts, 1)
+	assert.Equal(t, "b", notifier.alerts[0].Flight.ProviderName)
+	assert.Equal(t, 150.0, notifier.alerts[0].Flight.Price)
+}
+
+func newSQLiteRepository(t *testing.T) *SQLiteRepository {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// every connection of ":memory:" is a different database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := NewSQLiteRepository(db)
+	require.NoError(t, err)
+	return repo
+}
+
+func TestSchedulerCheckAll_SkipsDepartedSearches(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepository()
+	searcher := &priceSearcher{prices: []float64{100}}
+	scheduler := NewScheduler(repo, searcher, &recordingNotifier{}, time.Minute)
+	saveTestSearch(t, repo, entity.DefaultFlightQuery())
+
+	// the departure is 2030-06-01, it can be searched until the day is over
+	scheduler.now = func() time.Time { return time.Date(2030, 6, 2, 0, 0, 0, 0, time.UTC) }
+	scheduler.CheckAll(ctx)
+	assert.Zero(t, searcher.calls)
+
+	scheduler.now = func() time.Time { return time.Date(2030, 6, 1, 23, 0, 0, 0, time.UTC) }
+	scheduler.CheckAll(ctx)
+	assert.Equal(t, 1, searcher.calls)
+}
+
+func TestRepository_UserScoped(t *testing.T) {
+	repositories := map[string]func(t *testing.T) Repository{
+		"in memory": func(*testing.T) Repository { return NewInMemoryRepository() },
+		"sqlite":    func(t *testing.T) Repository { return newSQLiteRepository(t) },
+	}
+	for name, newRepo := range repositories {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			saved := saveTestSearch(t, repo, entity.DefaultFlightQuery())
+
+			_, err := repo.GetSearch(ctx, "other", saved.ID)
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.ErrorIs(t, repo.DeleteSearch(ctx, "other", saved.ID), ErrNotFound)
+
+			searches, err := repo.ListSearches(ctx, "other")
+			require.NoError(t, err)
+			assert.Empty(t, searches)
+
+			require.NoError(t, repo.DeleteSearch(ctx, "maria", saved.ID))
+			searches, err = repo.ListSearches(ctx, "maria")
+			require.NoError(t, err)
+			assert.Empty(t, searches)
+			assert.ErrorIs(t, repo.UpdateSearch(ctx, saved), ErrNotFound)
+		})
+	}
+}
+
+func TestSQLiteRepository_KeepsTheSearches(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLiteRepository(t)
+
+	query := entity.DefaultFlightQuery()
+	query.MaxStops = 1
+	query.Airlines = []string{"TP"}
+	saved := saveTestSearch(t, repo, query)
+
+	checkedAt := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	saved.LastCheckedAt = checkedAt
+	saved.LastPrice = 150
+	saved.LastNotifiedPrice = 150
+	require.NoError(t, repo.UpdateSearch(ctx, saved))
+	require.NoError(t, repo.AddObservation(ctx, entity.PriceObservation{SavedSearchID: saved.ID, Price: 180, Provider: "a", ObservedAt: checkedAt.Add(-time.Hour)}))
+	require.NoError(t, repo.AddObservation(ctx, entity.PriceObservation{SavedSearchID: saved.ID, Price: 150, Provider: "b", ObservedAt: checkedAt}))
+
+	all, err := repo.AllSearches(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, saved.Search, all[0].Search)
+	assert.Equal(t, query, all[0].Query)
+	assert.Equal(t, 200.0, all[0].PriceThreshold)
+	assert.Equal(t, "maria@example.com", all[0].Email)
+	assert.Equal(t, saved.CreatedAt.UnixMilli(), all[0].CreatedAt.UnixMilli())
+	assert.True(t, saved.ExpiresAt.Equal(all[0].ExpiresAt))
+	assert.True(t, checkedAt.Equal(all[0].LastCheckedAt))
+	assert.Equal(t, 150.0, all[0].LastNotifiedPrice)
+
+	observations, err := repo.Observations(ctx, saved.ID)
+	require.NoError(t, err)
+	require.Len(t, observations, 2)
+	assert.Equal(t, "a", observations[0].Provider)
+	assert.Equal(t, 150.0, observations[1].Price)
+
+	require.NoError(t, repo.DeleteSearch(ctx, "maria", saved.ID))
+	observations, err = repo.Observations(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.Empty(t, observations)
+}
+
+func TestSchedulerCheckAll_DeletesExpiredSearches(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepository()
+	searcher := &priceSearcher{prices: []float64{100}}
+	scheduler := NewScheduler(repo, searcher, &recordingNotifier{}, time.Minute)
+	saveTestSearch(t, repo, entity.DefaultFlightQuery())
+
+	// the token of the user expired, nobody can see the search
+	scheduler.now = func() time.Time { return time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC) }
+	scheduler.CheckAll(ctx)
+	assert.Zero(t, searcher.calls)
+
+	searches, err := repo.AllSearches(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, searches)
+}
+
+type recordingChannel struct {
+	messages []notify.Message
+}
+
+func (c *recordingChannel) Name() string { return "recording" }
+
+func (c *recordingChannel) Send(_ context.Context, msg notify.Message) error {
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+func TestChannelNotifier_SendsToTheEmailOfTheSearch(t *testing.T) {
+	channel := &recordingChannel{}
+	saved := entity.SavedSearch{
+		UserID:         "user-8f14e45fceea167a",
+		Search:         entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-06-01"},
+		PriceThreshold: 200,
+		Email:          "maria@example.com",
+	}
+	alert := entity.PriceAlert{SavedSearch: saved, Flight: entity.Flight{ProviderName: "a", Price: 150}}
+	require.NoError(t, NewChannelNotifier(channel).NotifyPriceDrop(context.Background(), alert))
+
+	require.Len(t, channel.messages, 1)
+	assert.Equal(t, "maria@example.com", channel.messages[0].Recipient)
+	assert.Equal(t, notify.EventPriceDrop, channel.messages[0].Event)
+}
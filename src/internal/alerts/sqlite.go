@@ -0,0 +1,244 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS saved_searches (
+	id                  TEXT PRIMARY KEY,
+	user_id             TEXT NOT NULL,
+	origin              TEXT NOT NULL,
+	destination         TEXT NOT NULL,
+	date_departure      TEXT NOT NULL,
+	filters             TEXT NOT NULL,
+	price_threshold     REAL NOT NULL,
+	email               TEXT NOT NULL DEFAULT '',
+	created_at          INTEGER NOT NULL,
+	expires_at          INTEGER NOT NULL DEFAULT 0,
+	last_checked_at     INTEGER NOT NULL DEFAULT 0,
+	last_price          REAL NOT NULL DEFAULT 0,
+	last_notified_price REAL NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_saved_searches_user
+	ON saved_searches (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS price_observations (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	saved_search_id TEXT NOT NULL,
+	price           REAL NOT NULL,
+	provider        TEXT NOT NULL,
+	observed_at     INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_price_observations_search
+	ON price_observations (saved_search_id, observed_at);
+`
+
+const searchColumns = `id, user_id, origin, destination, date_departure, filters, price_threshold,
+	email, created_at, expires_at, last_checked_at, last_price, last_notified_price`
+
+// SQLiteRepository keeps the saved searches and their prices in the SQLite
+// database of the price history, so they survive a restart
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository creates the tables that don't exist in the database
+func NewSQLiteRepository(db *sql.DB) (*SQLiteRepository, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("error creating the saved searches tables: %w", err)
+	}
+	// the databases created by older versions don't have these columns
+	for _, column := range []struct{ name, definition string }{
+		{"email", "TEXT NOT NULL DEFAULT ''"},
+		{"expires_at", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err := addColumn(db, column.name, column.definition); err != nil {
+			return nil, err
+		}
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
+// addColumn adds the column to the saved searches when it doesn't exist
+func addColumn(db *sql.DB, name, definition string) error {
+	var columns int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('saved_searches') WHERE name = ?`, name).Scan(&columns)
+	if err != nil {
+		return fmt.Errorf("error reading the saved searches columns: %w", err)
+	}
+	if columns > 0 {
+		return nil
+	}
+	if _, err := db.Exec(`ALTER TABLE saved_searches ADD COLUMN ` + name + ` ` + definition); err != nil {
+		return fmt.Errorf("error adding the column %s of the saved searches: %w", name, err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) SaveSearch(ctx context.Context, search entity.SavedSearch) (entity.SavedSearch, error) {
+	id, err := newID()
+	if err != nil {
+		return entity.SavedSearch{}, err
+	}
+	search.ID = id
+
+	filters, err := json.Marshal(search.Query)
+	if err != nil {
+		return entity.SavedSearch{}, err
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO saved_searches (`+searchColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		search.ID, search.UserID, search.Search.Origin, search.Search.Destination, search.Search.DateDeparture,
+		string(filters), search.PriceThreshold, search.Email, search.CreatedAt.UnixMilli(), unixMilli(search.ExpiresAt),
+		unixMilli(search.LastCheckedAt), search.LastPrice, search.LastNotifiedPrice)
+	if err != nil {
+		return entity.SavedSearch{}, fmt.Errorf("error inserting saved search: %w", err)
+	}
+	return search, nil
+}
+
+// UpdateSearch stores the result of the last check, the search itself doesn't change
+func (r *SQLiteRepository) UpdateSearch(ctx context.Context, search entity.SavedSearch) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE saved_searches
+		SET last_checked_at = ?, last_price = ?, last_notified_price = ? WHERE id = ?`,
+		unixMilli(search.LastCheckedAt), search.LastPrice, search.LastNotifiedPrice, search.ID)
+	if err != nil {
+		return fmt.Errorf("error updating saved search: %w", err)
+	}
+	return expectOneRow(result)
+}
+
+func (r *SQLiteRepository) GetSearch(ctx context.Context, userID, id string) (entity.SavedSearch, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+searchColumns+` FROM saved_searches WHERE id = ? AND user_id = ?`, id, userID)
+	search, err := scanSearch(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.SavedSearch{}, ErrNotFound
+	}
+	return search, err
+}
+
+func (r *SQLiteRepository) ListSearches(ctx context.Context, userID string) ([]entity.SavedSearch, error) {
+	return r.querySearches(ctx, `SELECT `+searchColumns+` FROM saved_searches WHERE user_id = ? ORDER BY created_at`, userID)
+}
+
+func (r *SQLiteRepository) AllSearches(ctx context.Context) ([]entity.SavedSearch, error) {
+	return r.querySearches(ctx, `SELECT `+searchColumns+` FROM saved_searches ORDER BY created_at`)
+}
+
+func (r *SQLiteRepository) DeleteSearch(ctx context.Context, userID, id string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting saved search: %w", err)
+	}
+	if err := expectOneRow(result); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM price_observations WHERE saved_search_id = ?`, id); err != nil {
+		return fmt.Errorf("error deleting price observations: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) AddObservation(ctx context.Context, observation entity.PriceObservation) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO price_observations (saved_search_id, price, provider, observed_at) VALUES (?, ?, ?, ?)`,
+		observation.SavedSearchID, observation.Price, observation.Provider, observation.ObservedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("error inserting price observation: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRepository) Observations(ctx context.Context, savedSearchID string) ([]entity.PriceObservation, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT saved_search_id, price, provider, observed_at
+		FROM price_observations WHERE saved_search_id = ? ORDER BY observed_at, id`, savedSearchID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying price observations: %w", err)
+	}
+	defer rows.Close()
+
+	observations := make([]entity.PriceObservation, 0)
+	for rows.Next() {
+		var o entity.PriceObservation
+		var observedAt int64
+		if err := rows.Scan(&o.SavedSearchID, &o.Price, &o.Provider, &observedAt); err != nil {
+			return nil, err
+		}
+		o.ObservedAt = time.UnixMilli(observedAt).UTC()
+		observations = append(observations, o)
+	}
+	return observations, rows.Err()
+}
+
+func (r *SQLiteRepository) querySearches(ctx context.Context, query string, args ...any) ([]entity.SavedSearch, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := make([]entity.SavedSearch, 0)
+	for rows.Next() {
+		search, err := scanSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, search)
+	}
+	return searches, rows.Err()
+}
+
+// scanSearch reads the searchColumns of a row
+func scanSearch(row interface{ Scan(dest ...any) error }) (entity.SavedSearch, error) {
+	var s entity.SavedSearch
+	var filters string
+	var createdAt, expiresAt, lastCheckedAt int64
+	err := row.Scan(&s.ID, &s.UserID, &s.Search.Origin, &s.Search.Destination, &s.Search.DateDeparture,
+		&filters, &s.PriceThreshold, &s.Email, &createdAt, &expiresAt, &lastCheckedAt, &s.LastPrice, &s.LastNotifiedPrice)
+	if err != nil {
+		return entity.SavedSearch{}, err
+	}
+	if err := json.Unmarshal([]byte(filters), &s.Query); err != nil {
+		return entity.SavedSearch{}, fmt.Errorf("invalid filters of saved search %s: %w", s.ID, err)
+	}
+
+	s.CreatedAt = time.UnixMilli(createdAt).UTC()
+	if expiresAt != 0 {
+		s.ExpiresAt = time.UnixMilli(expiresAt).UTC()
+	}
+	if lastCheckedAt != 0 {
+		s.LastCheckedAt = time.UnixMilli(lastCheckedAt).UTC()
+	}
+	return s, nil
+}
+
+func expectOneRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// unixMilli stores the zero time as 0, a search never checked
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
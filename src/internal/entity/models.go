@@ -1,6 +1,9 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 const (
 	DefaultTravelClass = "ECONOMY"
@@ -8,10 +11,33 @@ const (
 	DefaultAdults      = "1"
 )
 
+const (
+	SortByPrice     = "price"
+	SortByDuration  = "duration"
+	SortByDeparture = "departure"
+	SortByStops     = "stops"
+	SortByBest      = "best"
+	// SortByPriceWithBag sorts by the price plus a checked bag when the fare doesn't include one
+	SortByPriceWithBag = "price_with_bag"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+	DefaultTopN     = 3
+	MaxTopN         = 20
+
+	// DefaultCheckedBagPrice is the estimated price of a checked bag bought apart
+	DefaultCheckedBagPrice = 50
+)
+
 const (
 	AmadeusProvider           = "Amadeus"
 	SKyRapidProvider          = "Sky Rapid"
 	GoogleFlightRapidProvider = "Google Flight Rapid"
+	KiwiProvider              = "Kiwi"
+	DuffelProvider            = "Duffel"
 )
 
 type FlightSearchParam struct {
@@ -30,17 +56,107 @@ type FlightSearchResponse struct {
 }
 
 type Flight struct {
-	ProviderName    string    `json:"provider_name,omitempty"`
-	Price           float64   `json:"price"`
-	DurationMinutes int       `json:"total_duration_minutes"`
-	Segments        []Segment `json:"segments"`
+	// ItineraryID identifies the merged itinerary of a search, empty for the flights without segments
+	ItineraryID     string          `json:"itinerary_id,omitempty"`
+	ProviderName    string          `json:"provider_name,omitempty"`
+	Price           float64         `json:"price"`
+	DurationMinutes int             `json:"total_duration_minutes"`
+	Stops           int             `json:"stops"`
+	LayoverMinutes  []int           `json:"layover_minutes,omitempty"`
+	Segments        []Segment       `json:"segments"`
+	Offers          []ProviderOffer `json:"offers,omitempty"`
+	// Ref identifies the offer in its provider, to re-price or book it later
+	Ref *ProviderRef `json:"provider_ref,omitempty"`
+	// Raw is the offer as the provider sent it, the search keeps it in the
+	// server and only its token is sent to the clients
+	Raw json.RawMessage `json:"-"`
+	// BookingURL is the page of the provider to book the flight, empty when it doesn't send one
+	BookingURL string `json:"booking_url,omitempty"`
+	// the baggage allowances are nil when the provider doesn't send them
+	CheckedBags *Baggage `json:"checked_bags,omitempty"`
+	CabinBags   *Baggage `json:"cabin_bags,omitempty"`
+	// FareBrand is the fare family of the offer, e.g. "LIGHT" or "BASIC"
+	FareBrand string `json:"fare_brand,omitempty"`
+}
+
+// Baggage is the allowance of a fare for each passenger, in pieces or in weight
+type Baggage struct {
+	Quantity   int    `json:"quantity"`
+	Weight     int    `json:"weight,omitempty"`
+	WeightUnit string `json:"weight_unit,omitempty"`
+}
+
+// Included reports if the allowance has at least one bag, an unknown allowance has none
+func (b *Baggage) Included() bool {
+	return b != nil && (b.Quantity > 0 || b.Weight > 0)
+}
+
+// ProviderRef identifies the offer of a flight in its provider, so the pricing,
+// the booking or a debugging session can recover the original offer
+type ProviderRef struct {
+	Provider string `json:"provider"`
+	// ID is the id of the offer in the provider, empty when the provider has none
+	ID string `json:"id,omitempty"`
+	// Token is the opaque key of the original offer kept in the server, empty
+	// when it was not kept or it is not needed anymore
+	Token string `json:"token,omitempty"`
+	// Priceable is set when the provider can confirm the price of the offer
+	Priceable bool `json:"priceable,omitempty"`
+}
+
+// StoredOffer is the original offer of a flight kept in the server
+type StoredOffer struct {
+	Ref   ProviderRef     `json:"provider_ref"`
+	Price float64         `json:"price"`
+	Raw   json.RawMessage `json:"offer"`
+}
+
+// PricedOffer is the price of an offer confirmed by its provider, PriceChange
+// is the difference with the price found by the search
+type PricedOffer struct {
+	Provider          string     `json:"provider"`
+	Token             string     `json:"token"`
+	Currency          string     `json:"currency"`
+	PreviousPrice     float64    `json:"previous_price"`
+	Price             float64    `json:"price"`
+	PriceChange       float64    `json:"price_change"`
+	LastTicketingDate string     `json:"last_ticketing_date,omitempty"`
+	FareRules         []FareRule `json:"fare_rules,omitempty"`
+}
+
+// FareRule summarizes what the fare allows, e.g. the refunds or the changes
+type FareRule struct {
+	Category   string  `json:"category"`
+	Allowed    bool    `json:"allowed"`
+	MaxPenalty float64 `json:"max_penalty,omitempty"`
+}
+
+// ProviderOffer is the price a provider asks for an itinerary that can be
+// offered by more than one provider
+type ProviderOffer struct {
+	Provider   string       `json:"provider"`
+	Price      float64      `json:"price"`
+	Ref        *ProviderRef `json:"provider_ref,omitempty"`
+	BookingURL string       `json:"booking_url,omitempty"`
+	// a provider can sell the same itinerary with another fare
+	CheckedBags *Baggage `json:"checked_bags,omitempty"`
+	FareBrand   string   `json:"fare_brand,omitempty"`
 }
 
 type Segment struct {
 	DepartureAirport   string `json:"departureAirport"`
 	DestinationAirport string `json:"destinationAirport"`
-	DepartureTime      string `json:"departureTime"`
-	ArrivalTime        string `json:"arrivalTime"`
+	// times are in the local time zone of each airport and serialized as RFC 3339
+	DepartureTime    time.Time `json:"departureTime"`
+	ArrivalTime      time.Time `json:"arrivalTime"`
+	MarketingCarrier string    `json:"marketingCarrier,omitempty"`
+	OperatingCarrier string    `json:"operatingCarrier,omitempty"`
+	FlightNumber     string    `json:"flightNumber,omitempty"`
+	Aircraft         string    `json:"aircraft,omitempty"`
+	// TimeZoneUnknown is set when an airport of the segment is missing in the time
+	// zone dataset: its times are the local clock of the airport with a UTC offset,
+	// they can't be compared with the times of other airports
+	TimeZoneUnknown bool `json:"timeZoneUnknown,omitempty"`
 }
 
 type FlightPriceResponse struct {
@@ -48,9 +164,282 @@ type FlightPriceResponse struct {
 	DestinationName  string                 `json:"destinationName"`
 	Cheapest         Flight                 `json:"cheapest"`
 	Fastest          Flight                 `json:"fastest"`
+	Best             Flight                 `json:"best"`
+	Rankings         map[string][]Flight    `json:"rankings,omitempty"`
+	Flights          []Flight               `json:"flights"`
+	Pagination       Pagination             `json:"pagination"`
 	FlightByProvider []FlightSearchResponse `json:"flightByProvider"`
 }
 
+// FlexibleSearchParam searches a route on several days, either the departure
+// date plus/minus FlexDays or the range from DateFrom to DateTo
+type FlexibleSearchParam struct {
+	Origin        string `json:"origin" query:"origin" form:"origin"`
+	Destination   string `json:"destination" query:"destination" form:"destination"`
+	DateDeparture string `json:"date" query:"date" form:"date" validate:"required_without_all=DateFrom DateTo,omitempty,datetime=2006-01-02"`
+	FlexDays      int    `json:"flex_days" query:"flex_days" form:"flex_days" validate:"gte=0,lte=15"`
+	DateFrom      string `json:"date_from" query:"date_from" form:"date_from" validate:"required_with=DateTo,omitempty,datetime=2006-01-02"`
+	DateTo        string `json:"date_to" query:"date_to" form:"date_to" validate:"required_with=DateFrom,omitempty,datetime=2006-01-02"`
+}
+
+// DayPrice is the cheapest flight found for one day of a flexible search
+type DayPrice struct {
+	Date      string  `json:"date"`
+	Available bool    `json:"available"`
+	Price     float64 `json:"price,omitempty"`
+	Cheapest  *Flight `json:"cheapest,omitempty"`
+}
+
+type FlexibleSearchResponse struct {
+	OriginName      string     `json:"originName"`
+	DestinationName string     `json:"destinationName"`
+	Calendar        []DayPrice `json:"calendar"`
+	BestDate        string     `json:"bestDate,omitempty"`
+	BestPrice       float64    `json:"bestPrice,omitempty"`
+}
+
+// SearchUpdate is sent by a streamed search every time a provider answers,
+// Cheapest and Fastest are the best flights of all the providers so far
+type SearchUpdate struct {
+	Provider       string   `json:"provider"`
+	Failed         bool     `json:"failed,omitempty"`
+	Flights        []Flight `json:"flights"`
+	Cheapest       Flight   `json:"cheapest"`
+	Fastest        Flight   `json:"fastest"`
+	ProvidersDone  int      `json:"providersDone"`
+	ProvidersTotal int      `json:"providersTotal"`
+}
+
+// PriceCalendar has the cheapest price of each day of a month for a route
+type PriceCalendar struct {
+	OriginName      string     `json:"originName"`
+	DestinationName string     `json:"destinationName"`
+	Month           string     `json:"month"`
+	Days            []DayPrice `json:"days"`
+	MinPrice        float64    `json:"minPrice,omitempty"`
+	MaxPrice        float64    `json:"maxPrice,omitempty"`
+	BestDate        string     `json:"bestDate,omitempty"`
+}
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// SearchJobRequest is a search that runs in the background, either a list of
+// routes and dates or a flexible date search
+type SearchJobRequest struct {
+	Searches []FlightSearchParam  `json:"searches"`
+	Flexible *FlexibleSearchParam `json:"flexible,omitempty"`
+	Filters  FlightQuery          `json:"filters"`
+}
+
+// SearchJob is the state of a background search, the results are filled as
+// the searches finish
+type SearchJob struct {
+	ID         string                  `json:"id"`
+	Status     string                  `json:"status"`
+	Request    SearchJobRequest        `json:"request"`
+	Done       int                     `json:"done"`
+	Total      int                     `json:"total"`
+	Error      string                  `json:"error,omitempty"`
+	Results    []FlightPriceResponse   `json:"results,omitempty"`
+	Flexible   *FlexibleSearchResponse `json:"flexible,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	ExpiresAt  time.Time               `json:"expires_at"`
+}
+
+// BatchSearchRequest prices several routes at once, the filters apply to all of them
+type BatchSearchRequest struct {
+	Searches    []FlightSearchParam `json:"searches"`
+	Filters     FlightQuery         `json:"filters"`
+	Concurrency int                 `json:"concurrency"`
+}
+
+// RouteSummary is the result of one route of a batch search
+type RouteSummary struct {
+	Origin        string   `json:"origin"`
+	Destination   string   `json:"destination"`
+	DateDeparture string   `json:"date"`
+	Cheapest      *Flight  `json:"cheapest,omitempty"`
+	Fastest       *Flight  `json:"fastest,omitempty"`
+	Flights       int      `json:"flights"`
+	Providers     []string `json:"providers"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// SearchRecord is a search done by a user, kept to show the recent searches
+type SearchRecord struct {
+	UserID     string            `json:"user_id"`
+	Search     FlightSearchParam `json:"search"`
+	Query      FlightQuery       `json:"filters"`
+	SearchedAt time.Time         `json:"searched_at"`
+	// BestPrice is the cheapest flight that matched the filters, zero when none did
+	BestPrice float64  `json:"best_price"`
+	Providers []string `json:"providers"`
+}
+
+// PriceRecord is the cheapest price a provider returned for a route and
+// departure date at the moment of a search
+type PriceRecord struct {
+	Origin        string    `json:"origin"`
+	Destination   string    `json:"destination"`
+	DateDeparture string    `json:"date"`
+	Provider      string    `json:"provider"`
+	Price         float64   `json:"price"`
+	ObservedAt    time.Time `json:"observedAt"`
+}
+
+// PriceTrendPoint summarizes the prices observed in one day
+type PriceTrendPoint struct {
+	Date         string  `json:"date"`
+	MinPrice     float64 `json:"minPrice"`
+	AvgPrice     float64 `json:"avgPrice"`
+	MaxPrice     float64 `json:"maxPrice"`
+	Observations int     `json:"observations"`
+}
+
+// PriceHistory has the prices observed for a route and how they changed over time
+type PriceHistory struct {
+	Origin        string            `json:"origin"`
+	Destination   string            `json:"destination"`
+	DateDeparture string            `json:"date,omitempty"`
+	Records       []PriceRecord     `json:"records"`
+	Trend         []PriceTrendPoint `json:"trend"`
+	MinPrice      float64           `json:"minPrice,omitempty"`
+	AvgPrice      float64           `json:"avgPrice,omitempty"`
+	MaxPrice      float64           `json:"maxPrice,omitempty"`
+	CurrentPrice  float64           `json:"currentPrice,omitempty"`
+	// Advice compares the current price with the average: low, typical or high
+	Advice string `json:"advice,omitempty"`
+}
+
+// PriceHistoryQuery selects the records of a route, the empty fields match everything
+type PriceHistoryQuery struct {
+	Origin        string `query:"origin" validate:"required"`
+	Destination   string `query:"destination" validate:"required"`
+	DateDeparture string `query:"date" validate:"omitempty,datetime=2006-01-02"`
+	Provider      string `query:"provider"`
+	// Days limits the records to the last days, zero uses the default
+	Days int `query:"days" validate:"gte=0,lte=365"`
+}
+
+// SavedSearch is a search a user wants to follow, the scheduler runs it
+// periodically and sends an alert when the price drops below the threshold
+type SavedSearch struct {
+	ID             string            `json:"id"`
+	UserID         string            `json:"user_id"`
+	Search         FlightSearchParam `json:"search"`
+	Query          FlightQuery       `json:"filters"`
+	PriceThreshold float64           `json:"price_threshold"`
+	// Email receives the price alerts of the search, without it they only reach the operators
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is when the token of the user expires, nobody can list or delete
+	// the search after it so it is not checked anymore
+	ExpiresAt time.Time `json:"expires_at"`
+
+	LastCheckedAt     time.Time `json:"last_checked_at,omitempty"`
+	LastPrice         float64   `json:"last_price,omitempty"`
+	LastNotifiedPrice float64   `json:"last_notified_price,omitempty"`
+}
+
+// PriceObservation is the cheapest price found for a saved search at some point
+type PriceObservation struct {
+	SavedSearchID string    `json:"saved_search_id"`
+	Price         float64   `json:"price"`
+	Provider      string    `json:"provider"`
+	ObservedAt    time.Time `json:"observed_at"`
+}
+
+// PriceAlert is sent when a saved search finds a price below its threshold
+type PriceAlert struct {
+	SavedSearch   SavedSearch `json:"saved_search"`
+	Flight        Flight      `json:"flight"`
+	PreviousPrice float64     `json:"previous_price,omitempty"`
+}
+
+// FlightQuery holds the sorting, filtering and pagination options applied
+// to the merged list of flights returned by all the providers
+type FlightQuery struct {
+	SortBy string `json:"sort" query:"sort" form:"sort" validate:"omitempty,oneof=price duration departure stops best price_with_bag"`
+	Order  string `json:"order" query:"order" form:"order" validate:"omitempty,oneof=asc desc"`
+
+	// MaxStops negative value means no limit
+	MaxStops           int      `json:"max_stops" query:"max_stops" form:"max_stops"`
+	MaxPrice           float64  `json:"max_price" query:"max_price" form:"max_price" validate:"gte=0"`
+	MaxDurationMinutes int      `json:"max_duration" query:"max_duration" form:"max_duration" validate:"gte=0"`
+	Airlines           []string `json:"airlines" query:"airlines" form:"airlines"`
+	// CheckedBag keeps only the flights with a fare that includes a checked bag, of any provider
+	CheckedBag bool `json:"checked_bag" query:"checked_bag" form:"checked_bag"`
+
+	// time windows in the local time of the airport, format HH:MM
+	DepartureAfter  string `json:"departure_after" query:"departure_after" form:"departure_after" validate:"omitempty,datetime=15:04"`
+	DepartureBefore string `json:"departure_before" query:"departure_before" form:"departure_before" validate:"omitempty,datetime=15:04"`
+	ArrivalAfter    string `json:"arrival_after" query:"arrival_after" form:"arrival_after" validate:"omitempty,datetime=15:04"`
+	ArrivalBefore   string `json:"arrival_before" query:"arrival_before" form:"arrival_before" validate:"omitempty,datetime=15:04"`
+
+	Page     int `json:"page" query:"page" form:"page" validate:"gte=1"`
+	PageSize int `json:"page_size" query:"page_size" form:"page_size" validate:"gte=1,lte=100"`
+
+	// TopN is the number of flights returned for each ranking criterion
+	TopN    int            `json:"top" query:"top" form:"top" validate:"gte=1,lte=20"`
+	Weights RankingWeights `json:"weights"`
+}
+
+// RankingWeights sets how much each factor counts in the "best" score, the
+// weights are relative to each other so they don't need to add up to 1.
+// CheckedBagPrice is added to the fares without a checked bag by the bag
+// inclusive ranking
+type RankingWeights struct {
+	Price         float64 `json:"price" query:"weight_price" form:"weight_price" validate:"gte=0"`
+	Duration      float64 `json:"duration" query:"weight_duration" form:"weight_duration" validate:"gte=0"`
+	Stops         float64 `json:"stops" query:"weight_stops" form:"weight_stops" validate:"gte=0"`
+	DepartureTime float64 `json:"departure_time" query:"weight_departure" form:"weight_departure" validate:"gte=0"`
+
+	// preferred departure window in the local time of the airport, format HH:MM
+	PreferredDepartureAfter  string `json:"preferred_departure_after" query:"preferred_departure_after" form:"preferred_departure_after" validate:"omitempty,datetime=15:04"`
+	PreferredDepartureBefore string `json:"preferred_departure_before" query:"preferred_departure_before" form:"preferred_departure_before" validate:"omitempty,datetime=15:04"`
+
+	CheckedBagPrice float64 `json:"checked_bag_price" query:"checked_bag_price" form:"checked_bag_price" validate:"gte=0"`
+}
+
+// DefaultRankingWeights favours the price, then the duration and the stops
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{
+		Price:           0.5,
+		Duration:        0.3,
+		Stops:           0.15,
+		DepartureTime:   0.05,
+		CheckedBagPrice: DefaultCheckedBagPrice,
+	}
+}
+
+// DefaultFlightQuery returns the query used when the client does not send any option
+func DefaultFlightQuery() FlightQuery {
+	return FlightQuery{
+		SortBy:   SortByPrice,
+		Order:    SortOrderAsc,
+		MaxStops: -1,
+		Page:     1,
+		PageSize: DefaultPageSize,
+		TopN:     DefaultTopN,
+		Weights:  DefaultRankingWeights(),
+	}
+}
+
+type Pagination struct {
+	Page         int `json:"page"`
+	PageSize     int `json:"pageSize"`
+	TotalFlights int `json:"totalFlights"`
+	TotalPages   int `json:"totalPages"`
+}
+
 type FlightAmadeusResp struct {
 	Data []FlightOffer `json:"data"`
 }
@@ -65,14 +454,57 @@ type FlightGoogleResp struct {
 
 // FlightOffer represent amadeus response of a flight search
 type FlightOffer struct {
-	ID          string               `json:"id"`
-	Itineraries []ItinerariesAmadeus `json:"itineraries"`
-	Price       struct {
+	ID                string                   `json:"id"`
+	Raw               json.RawMessage          `json:"-"`
+	LastTicketingDate string                   `json:"lastTicketingDate"`
+	FareRules         FareRulesAmadeus         `json:"fareRules"`
+	Itineraries       []ItinerariesAmadeus     `json:"itineraries"`
+	TravelerPricings  []TravelerPricingAmadeus `json:"travelerPricings"`
+	Price             struct {
 		Total    string `json:"total"`
 		Currency string `json:"currency"`
 	} `json:"price"`
 }
 
+// FareRulesAmadeus are the rules of the fare, only sent by the pricing
+type FareRulesAmadeus struct {
+	Rules []struct {
+		Category         string `json:"category"`
+		MaxPenaltyAmount string `json:"maxPenaltyAmount"`
+		NotApplicable    bool   `json:"notApplicable"`
+	} `json:"rules"`
+}
+
+// FlightOffersPricingAmadeusResp is the response of the pricing of flight offers
+type FlightOffersPricingAmadeusResp struct {
+	Data struct {
+		FlightOffers []FlightOffer `json:"flightOffers"`
+	} `json:"data"`
+}
+
+// TravelerPricingAmadeus is the fare of a traveler of the offer
+type TravelerPricingAmadeus struct {
+	TravelerID           string               `json:"travelerId"`
+	FareDetailsBySegment []FareDetailsAmadeus `json:"fareDetailsBySegment"`
+}
+
+// FareDetailsAmadeus is the fare of a segment, the bags are nil when amadeus doesn't send them
+type FareDetailsAmadeus struct {
+	SegmentID           string       `json:"segmentId"`
+	Cabin               string       `json:"cabin"`
+	BrandedFare         string       `json:"brandedFare"`
+	BrandedFareLabel    string       `json:"brandedFareLabel"`
+	IncludedCheckedBags *BagsAmadeus `json:"includedCheckedBags"`
+	IncludedCabinBags   *BagsAmadeus `json:"includedCabinBags"`
+}
+
+// BagsAmadeus is a bag allowance, in pieces or in weight
+type BagsAmadeus struct {
+	Quantity   int    `json:"quantity"`
+	Weight     int    `json:"weight"`
+	WeightUnit string `json:"weightUnit"`
+}
+
 type ItinerariesAmadeus struct {
 	Duration string           `json:"duration"`
 	Segments []SegmentAmadeus `json:"segments"`
@@ -88,48 +520,195 @@ type SegmentAmadeus struct {
 		At       string `json:"at"`
 	} `json:"arrival"`
 	CarrierCode string `json:"carrierCode"`
+	Number      string `json:"number"`
+	Aircraft    struct {
+		Code string `json:"code"`
+	} `json:"aircraft"`
+	Operating struct {
+		CarrierCode string `json:"carrierCode"`
+	} `json:"operating"`
+	NumberOfStops int `json:"numberOfStops"`
 }
 
 // FlightItinerary represent flights-sky response of a flight search
 type FlightItinerary struct {
-	ID    string `json:"id"`
+	ID    string          `json:"id"`
+	Raw   json.RawMessage `json:"-"`
 	Price struct {
 		Amount float64 `json:"raw"`
 	} `json:"price"`
 	Legs []struct {
 		Segments  []SegmentSky `json:"segments"`
 		Duration  int          `json:"durationInMinutes"`
+		StopCount int          `json:"stopCount"`
 		Departure string       `json:"departure"`
 		Arrival   string       `json:"arrival"`
 	} `json:"legs"`
+	// DeepLink is the booking page of the itinerary
+	DeepLink string `json:"deeplink"`
 }
 
 // OtherFlight represent flights google response of a flight search
 type OtherFlight struct {
 	Price    float64          `json:"price"`
+	Raw      json.RawMessage  `json:"-"`
 	Duration int              `json:"duration"`
 	Segments []SegmentGoogleF `json:"segments"`
 	Stops    int              `json:"stops"`
+	// BookingURL is the booking page of the flight in google flights
+	BookingURL string `json:"bookingUrl"`
 }
 
 type SegmentGoogleF struct {
+	DepartureAirportCode string `json:"departureAirportCode"`
 	DepartureAirportName string `json:"departureAirportName"`
+	ArrivalAirportCode   string `json:"arrivalAirportCode"`
 	ArrivalAirportName   string `json:"arrivalAirportName"`
 	DepartureDate        string `json:"departureDate"`
 	ArrivalDate          string `json:"arrivalDate"`
 	DepartureTime        string `json:"departureTime"`
 	ArrivalTime          string `json:"arrivalTime"`
+	AirlineCode          string `json:"airlineCode"`
+	OperatingAirlineCode string `json:"operatingAirlineCode"`
+	FlightNumber         string `json:"flightNumber"`
+	AircraftName         string `json:"aircraftName"`
 }
 
 type SegmentSky struct {
 	Origin struct {
-		Name string `json:"name"`
+		Name        string `json:"name"`
+		DisplayCode string `json:"displayCode"`
 	} `json:"origin"`
 	Destination struct {
-		Name string `json:"name"`
+		Name        string `json:"name"`
+		DisplayCode string `json:"displayCode"`
 	} `json:"destination"`
-	DepartureDate string `json:"departure"`
-	ArrivalDate   string `json:"arrival"`
+	DepartureDate    string     `json:"departure"`
+	ArrivalDate      string     `json:"arrival"`
+	FlightNumber     string     `json:"flightNumber"`
+	MarketingCarrier CarrierSky `json:"marketingCarrier"`
+	OperatingCarrier CarrierSky `json:"operatingCarrier"`
+}
+
+type CarrierSky struct {
+	Name string `json:"name"`
+	// AlternateID is the IATA code of the carrier
+	AlternateID string `json:"alternateId"`
+}
+
+// FlightKiwiResp represent kiwi tequila response of a flight search
+type FlightKiwiResp struct {
+	Currency string          `json:"currency"`
+	Data     []ItineraryKiwi `json:"data"`
+}
+
+type ItineraryKiwi struct {
+	ID       string          `json:"id"`
+	Raw      json.RawMessage `json:"-"`
+	FlyFrom  string          `json:"flyFrom"`
+	FlyTo    string          `json:"flyTo"`
+	Price    float64         `json:"price"`
+	Duration struct {
+		// Departure is the duration of the outbound trip in seconds
+		Departure int `json:"departure"`
+	} `json:"duration"`
+	Route    []RouteKiwi `json:"route"`
+	DeepLink string      `json:"deep_link"`
+}
+
+type RouteKiwi struct {
+	FlyFrom string `json:"flyFrom"`
+	FlyTo   string `json:"flyTo"`
+	// the local times of kiwi come with a Z suffix, the UTC ones are used instead
+	UTCDeparture     string `json:"utc_departure"`
+	UTCArrival       string `json:"utc_arrival"`
+	Airline          string `json:"airline"`
+	FlightNo         int    `json:"flight_no"`
+	OperatingCarrier string `json:"operating_carrier"`
+	Equipment        string `json:"equipment"`
+	// Return is 1 in the segments of the return trip
+	Return int `json:"return"`
+}
+
+// OfferRequestDuffel is the search sent to duffel, the offers are retrieved with its id
+type OfferRequestDuffel struct {
+	Slices     []SliceRequestDuffel `json:"slices"`
+	Passengers []PassengerDuffel    `json:"passengers"`
+	CabinClass string               `json:"cabin_class"`
+}
+
+type SliceRequestDuffel struct {
+	Origin        string `json:"origin"`
+	Destination   string `json:"destination"`
+	DepartureDate string `json:"departure_date"`
+}
+
+type PassengerDuffel struct {
+	Type string `json:"type"`
+}
+
+type OfferRequestDuffelResp struct {
+	Data struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// OffersDuffelResp is a page of the offers of an offer request
+type OffersDuffelResp struct {
+	Data []OfferDuffel `json:"data"`
+	Meta struct {
+		// After is the cursor of the next page, empty in the last one
+		After string `json:"after"`
+	} `json:"meta"`
+}
+
+type OfferDuffel struct {
+	ID            string          `json:"id"`
+	Raw           json.RawMessage `json:"-"`
+	TotalAmount   string          `json:"total_amount"`
+	TotalCurrency string          `json:"total_currency"`
+	Slices        []SliceDuffel   `json:"slices"`
+}
+
+type SliceDuffel struct {
+	Duration      string          `json:"duration"`
+	FareBrandName string          `json:"fare_brand_name"`
+	Segments      []SegmentDuffel `json:"segments"`
+}
+
+type SegmentDuffel struct {
+	Origin      PlaceDuffel `json:"origin"`
+	Destination PlaceDuffel `json:"destination"`
+	// the times are local to each airport, without offset
+	DepartingAt      string `json:"departing_at"`
+	ArrivingAt       string `json:"arriving_at"`
+	MarketingCarrier struct {
+		IataCode string `json:"iata_code"`
+	} `json:"marketing_carrier"`
+	MarketingCarrierFlightNumber string `json:"marketing_carrier_flight_number"`
+	OperatingCarrier             struct {
+		IataCode string `json:"iata_code"`
+	} `json:"operating_carrier"`
+	Aircraft struct {
+		IataCode string `json:"iata_code"`
+	} `json:"aircraft"`
+	Passengers []SegmentPassengerDuffel `json:"passengers"`
+}
+
+// PlaceDuffel is an airport of a segment, with its IANA time zone
+type PlaceDuffel struct {
+	IataCode string `json:"iata_code"`
+	TimeZone string `json:"time_zone"`
+}
+
+// SegmentPassengerDuffel is the fare of a passenger in a segment
+type SegmentPassengerDuffel struct {
+	PassengerID string `json:"passenger_id"`
+	Baggages    []struct {
+		// Type is "checked" or "carry_on"
+		Type     string `json:"type"`
+		Quantity int    `json:"quantity"`
+	} `json:"baggages"`
 }
 
 type Provider struct {
@@ -0,0 +1,51 @@
+package entity
+
+import "encoding/json"
+
+// The offers of the providers keep the JSON they were decoded from in Raw, the
+// adapters pass it to the flights so the original offer can be recovered
+
+func (o *FlightOffer) UnmarshalJSON(data []byte) error {
+	type plain FlightOffer
+	if err := json.Unmarshal(data, (*plain)(o)); err != nil {
+		return err
+	}
+	o.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (it *FlightItinerary) UnmarshalJSON(data []byte) error {
+	type plain FlightItinerary
+	if err := json.Unmarshal(data, (*plain)(it)); err != nil {
+		return err
+	}
+	it.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (f *OtherFlight) UnmarshalJSON(data []byte) error {
+	type plain OtherFlight
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+	f.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (it *ItineraryKiwi) UnmarshalJSON(data []byte) error {
+	type plain ItineraryKiwi
+	if err := json.Unmarshal(data, (*plain)(it)); err != nil {
+		return err
+	}
+	it.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (o *OfferDuffel) UnmarshalJSON(data []byte) error {
+	type plain OfferDuffel
+	if err := json.Unmarshal(data, (*plain)(o)); err != nil {
+		return err
+	}
+	o.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+var flightsCSVHeader = []string{
+	"flight", "itinerary_id", "provider", "price", "total_duration_minutes", "stops",
+	"segment", "departure_airport", "arrival_airport", "departure_time", "arrival_time",
+	"marketing_carrier", "flight_number", "operating_carrier", "aircraft", "booking_url",
+}
+
+// FlightsCSV writes one row per segment of each flight, the flight columns are
+// repeated in its segments. A flight without segments has a single row
+func FlightsCSV(w io.Writer, flights []entity.Flight) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(flightsCSVHeader); err != nil {
+		return err
+	}
+
+	for i, f := range flights {
+		flight := []string{
+			strconv.Itoa(i + 1),
+			f.ItineraryID,
+			f.ProviderName,
+			strconv.FormatFloat(f.Price, 'f', 2, 64),
+			strconv.Itoa(f.DurationMinutes),
+			strconv.Itoa(f.Stops),
+		}
+
+		if len(f.Segments) == 0 {
+			row := append(flight, make([]string, len(flightsCSVHeader)-len(flight)-1)...)
+			row = append(row, f.BookingURL)
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for j, s := range f.Segments {
+			row := append(append([]string{}, flight...),
+				strconv.Itoa(j+1),
+				s.DepartureAirport,
+				s.DestinationAirport,
+				formatTime(s.DepartureTime, s.TimeZoneUnknown),
+				formatTime(s.ArrivalTime, s.TimeZoneUnknown),
+				s.MarketingCarrier,
+				s.FlightNumber,
+				s.OperatingCarrier,
+				s.Aircraft,
+				f.BookingURL,
+			)
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// FlightsJSON writes the flights with the route of the search
+func FlightsJSON(w io.Writer, resp entity.FlightPriceResponse, flights []entity.Flight) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		OriginName      string          `json:"originName"`
+		DestinationName string          `json:"destinationName"`
+		Flights         []entity.Flight `json:"flights"`
+	}{resp.OriginName, resp.DestinationName, flights})
+}
+
+// formatTime keeps the time zone of the airport, the zero times are left empty and
+// the times of an unknown time zone have no offset
+func formatTime(t time.Time, zoneUnknown bool) string {
+	if t.IsZero() {
+		return ""
+	}
+	if zoneUnknown {
+		return t.Format("2006-01-02T15:04:05")
+	}
+	return t.Format(time.RFC3339)
+}
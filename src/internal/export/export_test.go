@@ -0,0 +1,129 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFlight() entity.Flight {
+	madrid := time.FixedZone("CEST", 2*60*60)
+	lisbon := time.FixedZone("WEST", 60*60)
+	return entity.Flight{
+		ItineraryID:     "a1b2c3d4e5f60718",
+		ProviderName:    "amadeus",
+		Price:           180.5,
+		DurationMinutes: 220,
+		Stops:           1,
+		BookingURL:      "https://www.example.com/book?flight=IB3100&flight=TP1941",
+		Segments: []entity.Segment{
+			{
+				DepartureAirport:   "MAD",
+				DestinationAirport: "OPO",
+				DepartureTime:      time.Date(2025, 6, 1, 8, 0, 0, 0, madrid),
+				ArrivalTime:        time.Date(2025, 6, 1, 8, 30, 0, 0, lisbon),
+				MarketingCarrier:   "IB",
+				FlightNumber:       "3100",
+				OperatingCarrier:   "I2",
+			},
+			{
+				DepartureAirport:   "OPO",
+				DestinationAirport: "LIS",
+				DepartureTime:      time.Date(2025, 6, 1, 10, 0, 0, 0, lisbon),
+				ArrivalTime:        time.Date(2025, 6, 1, 10, 40, 0, 0, lisbon),
+				MarketingCarrier:   "TP",
+				FlightNumber:       "1941",
+			},
+		},
+	}
+}
+
+func TestFlightsCSV_OneRowPerSegment(t *testing.T) {
+	var buf bytes.Buffer
+	flights := []entity.Flight{testFlight(), {ProviderName: "sky", Price: 99, DurationMinutes: 150}}
+	require.NoError(t, FlightsCSV(&buf, flights))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, flightsCSVHeader, rows[0])
+	assert.Equal(t, []string{
+		"1", "a1b2c3d4e5f60718", "amadeus", "180.50", "220", "1",
+		"1", "MAD", "OPO", "2025-06-01T08:00:00+02:00", "2025-06-01T08:30:00+01:00",
+		"IB", "3100", "I2", "", "https://www.example.com/book?flight=IB3100&flight=TP1941",
+	}, rows[1])
+	assert.Equal(t, "2", rows[2][6])
+	assert.Equal(t, "TP", rows[2][11])
+	// the flight without segments keeps its columns
+	assert.Equal(t, []string{"2", "", "sky", "99.00", "150", "0"}, rows[3][:6])
+	assert.Len(t, rows[3], len(flightsCSVHeader))
+	assert.Empty(t, rows[3][len(flightsCSVHeader)-1])
+}
+
+func TestFlightsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	resp := entity.FlightPriceResponse{OriginName: "Madrid", DestinationName: "Lisbon"}
+	require.NoError(t, FlightsJSON(&buf, resp, []entity.Flight{testFlight()}))
+
+	var decoded struct {
+		OriginName string          `json:"originName"`
+		Flights    []entity.Flight `json:"flights"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "Madrid", decoded.OriginName)
+	require.Len(t, decoded.Flights, 1)
+	assert.Equal(t, "a1b2c3d4e5f60718", decoded.Flights[0].ItineraryID)
+}
+
+func TestItineraryICS(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, ItineraryICS(&buf, testFlight(), now))
+
+	ics := buf.String()
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 2, strings.Count(ics, "BEGIN:VEVENT"))
+	assert.Contains(t, ics, "UID:a1b2c3d4e5f60718-1@flight-price\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20250501T120000Z\r\n")
+	// the local times of the airports are written in UTC
+	assert.Contains(t, ics, "DTSTART:20250601T060000Z\r\nDTEND:20250601T073000Z\r\n")
+	assert.Contains(t, ics, "SUMMARY:Flight TP1941 OPO - LIS\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), icsLineLength)
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Contains(t, unfolded, `operated by I2. Price $180.50 with amadeus`)
+	assert.Contains(t, unfolded, `DESCRIPTION:Departure 2025-06-01 08:00 CEST (MAD)\, arrival`)
+	assert.Contains(t, unfolded, "URL:https://www.example.com/book?flight=IB3100&flight=TP1941\r\n")
+}
+
+func TestItineraryICS_UnknownTimeZone(t *testing.T) {
+	f := testFlight()
+	f.Segments[0].DepartureTime = time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	f.Segments[0].TimeZoneUnknown = true
+
+	var buf bytes.Buffer
+	require.NoError(t, ItineraryICS(&buf, f, time.Now()))
+
+	// the local clock is a floating time, without the Z of UTC
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	assert.Contains(t, unfolded, "DTSTART:20250601T080000\r\n")
+	assert.Contains(t, unfolded, `DESCRIPTION:Departure 2025-06-01 08:00 local time (MAD)`)
+}
+
+func TestItineraryICS_MissingTimes(t *testing.T) {
+	f := testFlight()
+	f.Segments[1].ArrivalTime = time.Time{}
+
+	err := ItineraryICS(&bytes.Buffer{}, f, time.Now())
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const (
+	icsTimeLayout = "20060102T150405Z"
+	// icsLocalTimeLayout is a floating time, the calendar shows the same clock in any time zone
+	icsLocalTimeLayout = "20060102T150405"
+	// icsLineLength is the max octets of a line, the longer ones are folded
+	icsLineLength = 75
+)
+
+var icsTextReplacer = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// ItineraryICS writes an iCalendar with one event for each segment of the flight,
+// the times are written in UTC so the calendar shows them in the local time of the user.
+// The segments without time zone have floating times with the clock of the airport
+func ItineraryICS(w io.Writer, f entity.Flight, now time.Time) error {
+	var b strings.Builder
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//flight-price//itinerary//EN")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+
+	for i, s := range f.Segments {
+		if s.DepartureTime.IsZero() || s.ArrivalTime.IsZero() {
+			return fmt.Errorf("the segment %d has no departure or arrival time", i+1)
+		}
+
+		flightNumber := s.MarketingCarrier + s.FlightNumber
+		summary := fmt.Sprintf("Flight %s %s - %s", flightNumber, s.DepartureAirport, s.DestinationAirport)
+		description := fmt.Sprintf("Departure %s (%s), arrival %s (%s)",
+			descriptionTime(s, s.DepartureTime), s.DepartureAirport,
+			descriptionTime(s, s.ArrivalTime), s.DestinationAirport)
+		if s.OperatingCarrier != "" && s.OperatingCarrier != s.MarketingCarrier {
+			description += ", operated by " + s.OperatingCarrier
+		}
+		if f.ProviderName != "" {
+			description += fmt.Sprintf(". Price $%.2f with %s", f.Price, f.ProviderName)
+		}
+
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, fmt.Sprintf("UID:%s-%d@flight-price", uid(f, s), i+1))
+		writeLine(&b, "DTSTAMP:"+now.UTC().Format(icsTimeLayout))
+		writeLine(&b, "DTSTART:"+eventTime(s, s.DepartureTime))
+		writeLine(&b, "DTEND:"+eventTime(s, s.ArrivalTime))
+		writeLine(&b, "SUMMARY:"+icsTextReplacer.Replace(summary))
+		writeLine(&b, "LOCATION:"+icsTextReplacer.Replace(s.DepartureAirport))
+		writeLine(&b, "DESCRIPTION:"+icsTextReplacer.Replace(description))
+		if f.BookingURL != "" {
+			writeLine(&b, "URL:"+f.BookingURL)
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// eventTime is the instant in UTC, or the local clock when the time zone is unknown
+func eventTime(s entity.Segment, t time.Time) string {
+	if s.TimeZoneUnknown {
+		return t.Format(icsLocalTimeLayout)
+	}
+	return t.UTC().Format(icsTimeLayout)
+}
+
+func descriptionTime(s entity.Segment, t time.Time) string {
+	if s.TimeZoneUnknown {
+		return t.Format("2006-01-02 15:04") + " local time"
+	}
+	return t.Format("2006-01-02 15:04 MST")
+}
+
+// uid keeps the same id when the itinerary is exported again, so the calendar updates the events
+func uid(f entity.Flight, s entity.Segment) string {
+	if f.ItineraryID != "" {
+		return f.ItineraryID
+	}
+	return s.DepartureTime.UTC().Format(icsTimeLayout) + "-" + s.MarketingCarrier + s.FlightNumber
+}
+
+// writeLine folds the lines longer than 75 octets, the next lines start with a
+// space that counts in its length
+func writeLine(b *strings.Builder, line string) {
+	limit := icsLineLength
+	for len(line) > limit {
+		cut := limit
+		// don't split a multi-byte character
+		for cut > 0 && !utf8Start(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func utf8Start(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,67 @@
+package ranking
+
+import (
+	"math"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+// PriceWithBag is the price of the flight with one checked bag, the bag price is
+// added to the fares that don't include one. A merged flight can take the fare
+// of another provider when it includes the bag and is cheaper in the end
+func PriceWithBag(f entity.Flight, bagPrice float64) float64 {
+	price := withBag(f.Price, f.CheckedBags, bagPrice)
+	for _, o := range f.Offers {
+		price = math.Min(price, withBag(o.Price, o.CheckedBags, bagPrice))
+	}
+	return price
+}
+
+// IncludesBag reports if the flight or one of the offers of the other providers
+// for the same itinerary includes a checked bag
+func IncludesBag(f entity.Flight) bool {
+	if f.CheckedBags.Included() {
+		return true
+	}
+	for _, o := range f.Offers {
+		if o.CheckedBags.Included() {
+			return true
+		}
+	}
+	return false
+}
+
+// BagFare returns the flight showing the cheapest fare that includes a checked bag,
+// it can be the offer of another provider. The cabin bags are not kept with the
+// offers, so they are unknown for that fare
+func BagFare(f entity.Flight) entity.Flight {
+	if f.CheckedBags.Included() {
+		return f
+	}
+
+	var fare *entity.ProviderOffer
+	for i, o := range f.Offers {
+		if o.CheckedBags.Included() && (fare == nil || o.Price < fare.Price) {
+			fare = &f.Offers[i]
+		}
+	}
+	if fare == nil {
+		return f
+	}
+
+	f.Price = fare.Price
+	f.ProviderName = fare.Provider
+	f.Ref = fare.Ref
+	f.BookingURL = fare.BookingURL
+	f.CheckedBags = fare.CheckedBags
+	f.CabinBags = nil
+	f.FareBrand = fare.FareBrand
+	return f
+}
+
+func withBag(price float64, bags *entity.Baggage, bagPrice float64) float64 {
+	if bags.Included() {
+		return price
+	}
+	return price + bagPrice
+}
@@ -0,0 +1,111 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const clockLayout = "15:04"
+
+// bestValue combines price, duration, stops and departure time. Each factor is
+// normalized between 0 and 1 among the candidates, so the score is the
+// weighted average of how far the flight is from the best candidate
+type bestValue struct {
+	weights  entity.RankingWeights
+	total    float64
+	price    bounds
+	duration bounds
+	maxStops float64
+
+	preferredFrom, preferredTo time.Duration
+	hasPreferred               bool
+}
+
+func newBestValue(candidates []entity.Flight, weights entity.RankingWeights) (Ranker, error) {
+	b := &bestValue{
+		weights:  weights,
+		total:    weights.Price + weights.Duration + weights.Stops + weights.DepartureTime,
+		price:    newBounds(),
+		duration: newBounds(),
+	}
+
+	for _, f := range candidates {
+		b.price.add(f.Price)
+		b.duration.add(float64(f.DurationMinutes))
+		b.maxStops = math.Max(b.maxStops, float64(f.Stops))
+	}
+
+	if weights.PreferredDepartureAfter != "" || weights.PreferredDepartureBefore != "" {
+		from, to := "00:00", "23:59"
+		if weights.PreferredDepartureAfter != "" {
+			from = weights.PreferredDepartureAfter
+		}
+		if weights.PreferredDepartureBefore != "" {
+			to = weights.PreferredDepartureBefore
+		}
+
+		var err error
+		if b.preferredFrom, err = parseClock(from); err != nil {
+			return nil, fmt.Errorf("invalid preferred departure: %w", err)
+		}
+		if b.preferredTo, err = parseClock(to); err != nil {
+			return nil, fmt.Errorf("invalid preferred departure: %w", err)
+		}
+		b.hasPreferred = true
+	}
+
+	return b, nil
+}
+
+func (b *bestValue) Score(f entity.Flight) float64 {
+	if b.total <= 0 {
+		return 0
+	}
+
+	score := b.weights.Price*normalize(f.Price, b.price.min, b.price.max) +
+		b.weights.Duration*normalize(float64(f.DurationMinutes), b.duration.min, b.duration.max) +
+		b.weights.Stops*normalize(float64(f.Stops), 0, b.maxStops) +
+		b.weights.DepartureTime*b.departurePenalty(f)
+
+	return score / b.total
+}
+
+// departurePenalty is 0 inside the preferred window and grows up to 1 when the
+// flight leaves 12 hours away from it
+func (b *bestValue) departurePenalty(f entity.Flight) float64 {
+	if !b.hasPreferred || len(f.Segments) == 0 || f.Segments[0].DepartureTime.IsZero() {
+		return 0
+	}
+
+	dep := f.Segments[0].DepartureTime
+	clock := time.Duration(dep.Hour())*time.Hour + time.Duration(dep.Minute())*time.Minute
+
+	inside := clock >= b.preferredFrom && clock <= b.preferredTo
+	if b.preferredFrom > b.preferredTo {
+		// the window wraps midnight
+		inside = clock >= b.preferredFrom || clock <= b.preferredTo
+	}
+	if inside {
+		return 0
+	}
+
+	distance := math.Min(clockDistance(clock, b.preferredFrom), clockDistance(clock, b.preferredTo))
+	return math.Min(distance/(12*time.Hour).Minutes(), 1)
+}
+
+// clockDistance returns the minutes between two times of the day
+func clockDistance(a, b time.Duration) float64 {
+	diff := math.Abs((a - b).Minutes())
+	return math.Min(diff, (24*time.Hour).Minutes()-diff)
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,147 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const (
+	Cheapest = "cheapest"
+	Fastest  = "fastest"
+	Best     = "best"
+	// CheapestWithBag ranks by the price with a checked bag, see PriceWithBag
+	CheapestWithBag = "cheapest-with-bag"
+)
+
+// Ranker scores a flight, a lower score ranks better
+type Ranker interface {
+	Score(f entity.Flight) float64
+}
+
+// Factory builds a ranker for a set of candidate flights. Rankers can use the
+// candidates to normalize the values they compare, e.g. min and max price
+type Factory func(candidates []entity.Flight, weights entity.RankingWeights) (Ranker, error)
+
+// RankerFunc allows to use a function as a Ranker
+type RankerFunc func(f entity.Flight) float64
+
+func (fn RankerFunc) Score(f entity.Flight) float64 {
+	return fn(f)
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Factory{
+		Cheapest: func([]entity.Flight, entity.RankingWeights) (Ranker, error) {
+			return RankerFunc(func(f entity.Flight) float64 { return f.Price }), nil
+		},
+		Fastest: func([]entity.Flight, entity.RankingWeights) (Ranker, error) {
+			return RankerFunc(func(f entity.Flight) float64 { return float64(f.DurationMinutes) }), nil
+		},
+		Best: newBestValue,
+		CheapestWithBag: func(_ []entity.Flight, weights entity.RankingWeights) (Ranker, error) {
+			return RankerFunc(func(f entity.Flight) float64 { return PriceWithBag(f, weights.CheckedBagPrice) }), nil
+		},
+	}
+)
+
+// Register adds a ranking criterion, an existing one with the same name is replaced
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = factory
+}
+
+// Criteria returns the names of the registered criteria sorted alphabetically
+func Criteria() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the ranker of the criterion for the candidate flights
+func New(criterion string, candidates []entity.Flight, weights entity.RankingWeights) (Ranker, error) {
+	mu.RLock()
+	factory, exists := registry[criterion]
+	mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("invalid ranking criterion: %s", criterion)
+	}
+	return factory(candidates, weights)
+}
+
+// Sort orders the flights in place by the criterion, ties are broken by price
+func Sort(flights []entity.Flight, criterion string, weights entity.RankingWeights) error {
+	ranker, err := New(criterion, flights, weights)
+	if err != nil {
+		return err
+	}
+
+	scores := make([]float64, len(flights))
+	indexes := make([]int, len(flights))
+	for i, f := range flights {
+		scores[i] = ranker.Score(f)
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		ia, ib := indexes[a], indexes[b]
+		if scores[ia] != scores[ib] {
+			return scores[ia] < scores[ib]
+		}
+		return flights[ia].Price < flights[ib].Price
+	})
+
+	sorted := make([]entity.Flight, len(flights))
+	for i, idx := range indexes {
+		sorted[i] = flights[idx]
+	}
+	copy(flights, sorted)
+	return nil
+}
+
+// Top returns the n best flights by the criterion without modifying the input
+func Top(flights []entity.Flight, criterion string, weights entity.RankingWeights, n int) ([]entity.Flight, error) {
+	ranked := make([]entity.Flight, len(flights))
+	copy(ranked, flights)
+
+	if err := Sort(ranked, criterion, weights); err != nil {
+		return nil, err
+	}
+	if n < len(ranked) {
+		ranked = ranked[:n]
+	}
+	return ranked, nil
+}
+
+// normalize scales the value between 0 and 1 for the range of the candidates
+func normalize(value, min, max float64) float64 {
+	if max <= min {
+		return 0
+	}
+	return (value - min) / (max - min)
+}
+
+type bounds struct {
+	min, max float64
+}
+
+func newBounds() bounds {
+	return bounds{min: math.MaxFloat64, max: -math.MaxFloat64}
+}
+
+func (b *bounds) add(value float64) {
+	b.min = math.Min(b.min, value)
+	b.max = math.Max(b.max, value)
+}
@@ -0,0 +1,126 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func flightAt(price float64, duration, stops, hour int) entity.Flight {
+	return entity.Flight{
+		Price:           price,
+		DurationMinutes: duration,
+		Stops:           stops,
+		Segments: []entity.Segment{
+			{DepartureTime: time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)},
+		},
+	}
+}
+
+func TestTop_CheapestAndFastest(t *testing.T) {
+	flights := []entity.Flight{
+		flightAt(300, 100, 0, 8),
+		flightAt(100, 400, 2, 8),
+		flightAt(200, 200, 1, 8),
+	}
+
+	cheapest, err := Top(flights, Cheapest, entity.DefaultRankingWeights(), 2)
+	require.NoError(t, err)
+	require.Len(t, cheapest, 2)
+	assert.Equal(t, 100.0, cheapest[0].Price)
+	assert.Equal(t, 200.0, cheapest[1].Price)
+
+	fastest, err := Top(flights, Fastest, entity.DefaultRankingWeights(), 5)
+	require.NoError(t, err)
+	require.Len(t, fastest, 3)
+	assert.Equal(t, 100, fastest[0].DurationMinutes)
+
+	// the input is not modified
+	assert.Equal(t, 300.0, flights[0].Price)
+}
+
+func TestTop_BestUsesWeights(t *testing.T) {
+	flights := []entity.Flight{
+		flightAt(100, 600, 2, 6),
+		flightAt(120, 120, 0, 6),
+		flightAt(400, 100, 0, 6),
+	}
+
+	best, err := Top(flights, Best, entity.DefaultRankingWeights(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 120.0, best[0].Price)
+
+	onlyPrice := entity.RankingWeights{Price: 1}
+	best, err = Top(flights, Best, onlyPrice, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, best[0].Price)
+}
+
+func TestTop_BestPreferredDeparture(t *testing.T) {
+	flights := []entity.Flight{
+		flightAt(100, 120, 0, 6),
+		flightAt(100, 120, 0, 18),
+	}
+
+	weights := entity.RankingWeights{
+		Price:                    1,
+		DepartureTime:            1,
+		PreferredDepartureAfter:  "17:00",
+		PreferredDepartureBefore: "20:00",
+	}
+	best, err := Top(flights, Best, weights, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 18, best[0].Segments[0].DepartureTime.Hour())
+}
+
+func TestTop_CheapestWithBag(t *testing.T) {
+	basic := flightAt(100, 100, 0, 8)
+	basic.CheckedBags = &entity.Baggage{}
+	unknown := flightAt(120, 100, 0, 8)
+	withBag := flightAt(160, 100, 0, 8)
+	withBag.CheckedBags = &entity.Baggage{Weight: 23, WeightUnit: "KG"}
+
+	weights := entity.RankingWeights{CheckedBagPrice: 50}
+	top, err := Top([]entity.Flight{basic, unknown, withBag}, CheapestWithBag, weights, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{100, 160, 120}, []float64{top[0].Price, top[1].Price, top[2].Price})
+
+	// a merged flight can use the offer of another provider that includes the bag
+	merged := flightAt(100, 100, 0, 8)
+	merged.Offers = []entity.ProviderOffer{
+		{Provider: "a", Price: 100},
+		{Provider: "b", Price: 130, CheckedBags: &entity.Baggage{Quantity: 1}},
+	}
+	assert.Equal(t, 130.0, PriceWithBag(merged, 50))
+	assert.Equal(t, 110.0, PriceWithBag(merged, 10))
+
+	fare := BagFare(merged)
+	assert.Equal(t, "b", fare.ProviderName)
+	assert.Equal(t, 130.0, fare.Price)
+	assert.Equal(t, 100.0, BagFare(basic).Price)
+}
+
+func TestRegister_CustomCriterion(t *testing.T) {
+	Register("fewest-stops", func([]entity.Flight, entity.RankingWeights) (Ranker, error) {
+		return RankerFunc(func(f entity.Flight) float64 { return float64(f.Stops) }), nil
+	})
+	defer func() {
+		mu.Lock()
+		delete(registry, "fewest-stops")
+		mu.Unlock()
+	}()
+
+	assert.Contains(t, Criteria(), "fewest-stops")
+
+	top, err := Top([]entity.Flight{flightAt(100, 100, 2, 8), flightAt(300, 100, 0, 8)}, "fewest-stops", entity.RankingWeights{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, top[0].Stops)
+}
+
+func TestNew_UnknownCriterion(t *testing.T) {
+	_, err := New("unknown", nil, entity.DefaultRankingWeights())
+	require.Error(t, err)
+}
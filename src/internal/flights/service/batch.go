@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/flights/ranking"
+)
+
+const DefaultBatchConcurrency = 4
+
+// SearchBatch searches every route running at most concurrency searches at the
+// same time, the summaries keep the order of the searches
+func (s *FlightService) SearchBatch(ctx context.Context, searches []entity.FlightSearchParam, query entity.FlightQuery, concurrency int) []entity.RouteSummary {
+	if concurrency < 1 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	summaries := make([]entity.RouteSummary, len(searches))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, search := range searches {
+		wg.Add(1)
+		go func(i int, search entity.FlightSearchParam) {
+			defer wg.Done()
+			summaries[i] = entity.RouteSummary{
+				Origin:        search.Origin,
+				Destination:   search.Destination,
+				DateDeparture: search.DateDeparture,
+				Providers:     []string{},
+			}
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				summaries[i].Error = ctx.Err().Error()
+				return
+			}
+
+			summaries[i] = s.summarizeRoute(ctx, search, query, summaries[i])
+		}(i, search)
+	}
+	wg.Wait()
+
+	return summaries
+}
+
+func (s *FlightService) summarizeRoute(ctx context.Context, search entity.FlightSearchParam, query entity.FlightQuery, summary entity.RouteSummary) entity.RouteSummary {
+	resp := s.SearchFlights(ctx, search)
+	if len(resp.FlightByProvider) == 0 {
+		summary.Error = "no provider answered"
+		return summary
+	}
+
+	for _, r := range resp.FlightByProvider {
+		summary.Providers = append(summary.Providers, r.Provider)
+	}
+
+	resp, err := ApplyQuery(resp, query)
+	if err != nil {
+		summary.Error = err.Error()
+		return summary
+	}
+
+	summary.Flights = resp.Pagination.TotalFlights
+	if cheapest := resp.Rankings[ranking.Cheapest]; len(cheapest) > 0 {
+		summary.Cheapest = &cheapest[0]
+	}
+	if fastest := resp.Rankings[ranking.Fastest]; len(fastest) > 0 {
+		summary.Fastest = &fastest[0]
+	}
+	return summary
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchBatch(t *testing.T) {
+	provider := &priceByDateProvider{prices: map[string]float64{
+		"2030-05-10": 120,
+		"2030-05-11": 90,
+		"2030-05-12": 200,
+	}}
+	service := NewFlightService(provider)
+
+	searches := []entity.FlightSearchParam{
+		{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10"},
+		{Origin: "Madrid", Destination: "Paris", DateDeparture: "2030-05-11"},
+		{Origin: "Madrid", Destination: "Rome", DateDeparture: "2030-05-12"},
+		{Origin: "Madrid", Destination: "Berlin", DateDeparture: "2030-05-13"},
+	}
+	query := entity.DefaultFlightQuery()
+	query.MaxPrice = 150
+
+	summaries := service.SearchBatch(context.Background(), searches, query, 2)
+
+	require.Len(t, summaries, 4)
+	assert.LessOrEqual(t, provider.maxRunning, 2)
+
+	assert.Equal(t, "Lisbon", summaries[0].Destination)
+	require.NotNil(t, summaries[0].Cheapest)
+	assert.Equal(t, 120.0, summaries[0].Cheapest.Price)
+	assert.Equal(t, 120.0, summaries[0].Fastest.Price)
+	assert.Equal(t, 1, summaries[0].Flights)
+	assert.Equal(t, []string{"stub"}, summaries[0].Providers)
+
+	assert.Equal(t, 90.0, summaries[1].Cheapest.Price)
+
+	// the only flight is filtered out by the max price
+	assert.Nil(t, summaries[2].Cheapest)
+	assert.Equal(t, 0, summaries[2].Flights)
+	assert.Empty(t, summaries[2].Error)
+
+	assert.Equal(t, "no provider answered", summaries[3].Error)
+}
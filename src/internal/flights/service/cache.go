@@ -0,0 +1,77 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const DefaultCacheTTL = 5 * time.Minute
+
+type cacheEntry struct {
+	resp      entity.FlightPriceResponse
+	expiresAt time.Time
+}
+
+// searchCache keeps the responses of the providers for a route and date for a
+// while, so repeated and flexible date searches don't call the providers again
+type searchCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[entity.FlightSearchParam]cacheEntry
+}
+
+func newSearchCache(ttl time.Duration) *searchCache {
+	return &searchCache{
+		ttl:     ttl,
+		entries: make(map[entity.FlightSearchParam]cacheEntry),
+	}
+}
+
+func cacheKey(criteria entity.FlightSearchParam) entity.FlightSearchParam {
+	return entity.FlightSearchParam{
+		Origin:        strings.ToLower(strings.TrimSpace(criteria.Origin)),
+		Destination:   strings.ToLower(strings.TrimSpace(criteria.Destination)),
+		DateDeparture: strings.TrimSpace(criteria.DateDeparture),
+	}
+}
+
+func (c *searchCache) get(criteria entity.FlightSearchParam) (entity.FlightPriceResponse, bool) {
+	if c.ttl <= 0 {
+		return entity.FlightPriceResponse{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(criteria)
+	entry, exists := c.entries[key]
+	if !exists {
+		return entity.FlightPriceResponse{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return entity.FlightPriceResponse{}, false
+	}
+	return entry.resp, true
+}
+
+func (c *searchCache) set(criteria entity.FlightSearchParam, resp entity.FlightPriceResponse) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// drop the expired entries so the map does not grow forever
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[cacheKey(criteria)] = cacheEntry{resp: resp, expiresAt: now.Add(c.ttl)}
+}
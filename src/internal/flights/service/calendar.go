@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const monthLayout = "2006-01"
+
+// PriceCalendar returns the cheapest price of each day of the month, format
+// YYYY-MM. The days in the past are not searched
+func (s *FlightService) PriceCalendar(ctx context.Context, origin, destination, month string) (entity.PriceCalendar, error) {
+	start, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return entity.PriceCalendar{}, fmt.Errorf("invalid month: %w", err)
+	}
+	end := start.AddDate(0, 1, -1)
+
+	resp, err := s.SearchFlexibleDates(ctx, entity.FlexibleSearchParam{
+		Origin:      origin,
+		Destination: destination,
+		DateFrom:    start.Format(dateLayout),
+		DateTo:      end.Format(dateLayout),
+	})
+	if err != nil {
+		return entity.PriceCalendar{}, err
+	}
+
+	calendar := entity.PriceCalendar{
+		OriginName:      resp.OriginName,
+		DestinationName: resp.DestinationName,
+		Month:           month,
+		Days:            make([]entity.DayPrice, 0, len(resp.Calendar)),
+		BestDate:        resp.BestDate,
+	}
+	for _, day := range resp.Calendar {
+		// only the minimum is needed for the calendar, not the whole flight
+		day.Cheapest = nil
+		calendar.Days = append(calendar.Days, day)
+
+		if !day.Available {
+			continue
+		}
+		if calendar.MinPrice == 0 || day.Price < calendar.MinPrice {
+			calendar.MinPrice = day.Price
+		}
+		if day.Price > calendar.MaxPrice {
+			calendar.MaxPrice = day.Price
+		}
+	}
+	return calendar, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const normalizedTimeLayout = "2006-01-02T15:04"
+
+// dedupeFlights merges the itineraries that different providers return for the
+// same physical flights. The merged flight keeps the best price and lists the
+// offer of every provider sorted by price.
+func dedupeFlights(flights []entity.Flight) []entity.Flight {
+	merged := make([]entity.Flight, 0, len(flights))
+	indexByKey := make(map[string]int, len(flights))
+
+	for _, f := range flights {
+		// flights without segments can't be compared with others
+		if len(f.Segments) == 0 {
+			f.Offers = []entity.ProviderOffer{providerOffer(f)}
+			merged = append(merged, f)
+			continue
+		}
+
+		key := itineraryKey(f)
+		if unknownTimeZone(f) {
+			// its local times are not comparable with the instants of other providers
+			key += "|" + f.ProviderName
+		}
+		i, exists := indexByKey[key]
+		if !exists {
+			f.ItineraryID = itineraryID(key)
+			f.Offers = []entity.ProviderOffer{providerOffer(f)}
+			indexByKey[key] = len(merged)
+			merged = append(merged, f)
+			continue
+		}
+
+		merged[i].Offers = addOffer(merged[i].Offers, providerOffer(f))
+		if f.Price < merged[i].Price {
+			merged[i].Price = f.Price
+			merged[i].ProviderName = f.ProviderName
+			merged[i].Ref = f.Ref
+			merged[i].BookingURL = f.BookingURL
+			merged[i].CheckedBags = f.CheckedBags
+			merged[i].CabinBags = f.CabinBags
+			merged[i].FareBrand = f.FareBrand
+		}
+	}
+
+	for i := range merged {
+		sort.SliceStable(merged[i].Offers, func(a, b int) bool {
+			return merged[i].Offers[a].Price < merged[i].Offers[b].Price
+		})
+	}
+	return merged
+}
+
+// providerOffer is the offer of the provider of the flight
+func providerOffer(f entity.Flight) entity.ProviderOffer {
+	return entity.ProviderOffer{
+		Provider:    f.ProviderName,
+		Price:       f.Price,
+		Ref:         f.Ref,
+		BookingURL:  f.BookingURL,
+		CheckedBags: f.CheckedBags,
+		FareBrand:   f.FareBrand,
+	}
+}
+
+// addOffer adds the offer keeping only the cheapest one for each provider
+func addOffer(offers []entity.ProviderOffer, offer entity.ProviderOffer) []entity.ProviderOffer {
+	for i, o := range offers {
+		if o.Provider == offer.Provider {
+			if offer.Price < o.Price {
+				offers[i] = offer
+			}
+			return offers
+		}
+	}
+	return append(offers, offer)
+}
+
+// itineraryKey identifies a flight by its normalized segments
+func itineraryKey(f entity.Flight) string {
+	parts := make([]string, 0, len(f.Segments))
+	for _, s := range f.Segments {
+		parts = append(parts, strings.Join([]string{
+			normalizeCode(s.DepartureAirport),
+			normalizeTime(s.DepartureTime),
+			normalizeCode(s.DestinationAirport),
+			normalizeTime(s.ArrivalTime),
+			normalizeCode(s.MarketingCarrier),
+			normalizeFlightNumber(s.FlightNumber),
+		}, "|"))
+	}
+	return strings.Join(parts, "/")
+}
+
+// unknownTimeZone reports if a segment of the flight has no time zone
+func unknownTimeZone(f entity.Flight) bool {
+	for _, s := range f.Segments {
+		if s.TimeZoneUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+// itineraryID is a short and stable id of the itinerary key, used in the urls
+func itineraryID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// normalizeFlightNumber removes the leading zeros, providers send "0123" or "123"
+func normalizeFlightNumber(number string) string {
+	number = strings.TrimLeft(strings.TrimSpace(number), "0")
+	return strings.ToUpper(number)
+}
+
+// normalizeTime drops the seconds and compares the instants in UTC
+func normalizeTime(t time.Time) string {
+	return t.UTC().Format(normalizedTimeLayout)
+}
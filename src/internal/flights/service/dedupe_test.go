@@ -0,0 +1,164 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeFlights_MergesSameItinerary(t *testing.T) {
+	flights := []entity.Flight{
+		{
+			ProviderName: "Amadeus",
+			Price:        120,
+			Segments: []entity.Segment{{
+				DepartureAirport: "MAD", DestinationAirport: "LIS",
+				DepartureTime: mustTime("2024-01-01T08:00:00+01:00"), ArrivalTime: mustTime("2024-01-01T07:40:00Z"),
+				MarketingCarrier: "TP", FlightNumber: "1017",
+			}},
+		},
+		{
+			ProviderName: "google",
+			Price:        110,
+			Segments: []entity.Segment{{
+				DepartureAirport: "mad", DestinationAirport: "lis",
+				DepartureTime: mustTime("2024-01-01T07:00:00Z"), ArrivalTime: mustTime("2024-01-01T07:40:00Z"),
+				MarketingCarrier: "tp", FlightNumber: "01017",
+			}},
+		},
+		{
+			ProviderName: "google",
+			Price:        90,
+			Segments: []entity.Segment{{
+				DepartureAirport: "MAD", DestinationAirport: "LIS",
+				DepartureTime: mustTime("2024-01-01T12:00:00Z"), ArrivalTime: mustTime("2024-01-01T12:40:00Z"),
+				MarketingCarrier: "IB", FlightNumber: "3100",
+			}},
+		},
+		{ProviderName: "flights-sky", Price: 80},
+		{ProviderName: "flights-sky", Price: 85},
+	}
+
+	merged := dedupeFlights(flights)
+	require.Len(t, merged, 4)
+
+	assert.Equal(t, 110.0, merged[0].Price)
+	assert.Equal(t, "google", merged[0].ProviderName)
+	assert.Equal(t, []entity.ProviderOffer{
+		{Provider: "google", Price: 110},
+		{Provider: "Amadeus", Price: 120},
+	}, merged[0].Offers)
+
+	assert.Len(t, merged[1].Offers, 1)
+	assert.Len(t, merged[2].Offers, 1)
+	assert.Len(t, merged[3].Offers, 1)
+
+	// the id of the itinerary is the same in every search
+	assert.Len(t, merged[0].ItineraryID, 16)
+	assert.Equal(t, merged[0].ItineraryID, dedupeFlights(flights[:1])[0].ItineraryID)
+	assert.NotEqual(t, merged[0].ItineraryID, merged[1].ItineraryID)
+	assert.Empty(t, merged[2].ItineraryID)
+}
+
+func TestDedupeFlights_KeepsCheapestOfferPerProvider(t *testing.T) {
+	segments := []entity.Segment{{
+		DepartureAirport: "MAD", DestinationAirport: "LIS",
+		DepartureTime: mustTime("2024-01-01T08:00:00Z"), ArrivalTime: mustTime("2024-01-01T08:40:00Z"),
+	}}
+	flights := []entity.Flight{
+		{ProviderName: "flights-sky", Price: 150, Segments: segments},
+		{ProviderName: "flights-sky", Price: 130, Segments: segments},
+	}
+
+	merged := dedupeFlights(flights)
+	require.Len(t, merged, 1)
+	assert.Equal(t, []entity.ProviderOffer{{Provider: "flights-sky", Price: 130}}, merged[0].Offers)
+	assert.Equal(t, 130.0, merged[0].Price)
+}
+
+func TestDedupeFlights_KeepsTheProviderRefs(t *testing.T) {
+	segments := []entity.Segment{{
+		DepartureAirport: "MAD", DestinationAirport: "LIS",
+		DepartureTime: mustTime("2024-01-01T08:00:00Z"), ArrivalTime: mustTime("2024-01-01T08:40:00Z"),
+	}}
+	first := &entity.ProviderRef{Provider: "duffel", ID: "off_1"}
+	second := &entity.ProviderRef{Provider: "duffel", ID: "off_2"}
+	flights := []entity.Flight{
+		{ProviderName: "duffel", Price: 150, Segments: segments, Ref: first},
+		{ProviderName: "google", Price: 140, Segments: segments},
+		{ProviderName: "duffel", Price: 130, Segments: segments, Ref: second},
+	}
+
+	merged := dedupeFlights(flights)
+	require.Len(t, merged, 1)
+	assert.Equal(t, second, merged[0].Ref)
+	assert.Equal(t, []entity.ProviderOffer{
+		{Provider: "duffel", Price: 130, Ref: second},
+		{Provider: "google", Price: 140},
+	}, merged[0].Offers)
+}
+
+func TestDedupeFlights_KeepsTheBookingURLs(t *testing.T) {
+	segments := []entity.Segment{{
+		DepartureAirport: "MAD", DestinationAirport: "LIS",
+		DepartureTime: mustTime("2024-01-01T08:00:00Z"), ArrivalTime: mustTime("2024-01-01T08:40:00Z"),
+	}}
+	flights := []entity.Flight{
+		{ProviderName: "google", Price: 140, Segments: segments, BookingURL: "https://google.example/book/1"},
+		{ProviderName: "flights-sky", Price: 120, Segments: segments, BookingURL: "https://sky.example/book/1"},
+		{ProviderName: "Amadeus", Price: 130, Segments: segments},
+	}
+
+	merged := dedupeFlights(flights)
+	require.Len(t, merged, 1)
+	assert.Equal(t, "https://sky.example/book/1", merged[0].BookingURL)
+	assert.Equal(t, []entity.ProviderOffer{
+		{Provider: "flights-sky", Price: 120, BookingURL: "https://sky.example/book/1"},
+		{Provider: "Amadeus", Price: 130},
+		{Provider: "google", Price: 140, BookingURL: "https://google.example/book/1"},
+	}, merged[0].Offers)
+}
+
+func TestDedupeFlights_KeepsTheFareOfTheCheapestOffer(t *testing.T) {
+	segments := []entity.Segment{{
+		DepartureAirport: "MAD", DestinationAirport: "LIS",
+		DepartureTime: mustTime("2024-01-01T08:00:00Z"), ArrivalTime: mustTime("2024-01-01T08:40:00Z"),
+	}}
+	classic := &entity.Baggage{Quantity: 1}
+	flights := []entity.Flight{
+		{ProviderName: "Amadeus", Price: 150, Segments: segments, CheckedBags: classic, CabinBags: &entity.Baggage{Quantity: 1}, FareBrand: "CLASSIC"},
+		{ProviderName: "duffel", Price: 110, Segments: segments, CheckedBags: &entity.Baggage{}, FareBrand: "Basic"},
+	}
+
+	merged := dedupeFlights(flights)
+	require.Len(t, merged, 1)
+	assert.Equal(t, &entity.Baggage{}, merged[0].CheckedBags)
+	assert.Nil(t, merged[0].CabinBags)
+	assert.Equal(t, "Basic", merged[0].FareBrand)
+	assert.Equal(t, []entity.ProviderOffer{
+		{Provider: "duffel", Price: 110, CheckedBags: &entity.Baggage{}, FareBrand: "Basic"},
+		{Provider: "Amadeus", Price: 150, CheckedBags: classic, FareBrand: "CLASSIC"},
+	}, merged[0].Offers)
+}
+
+func TestDedupeFlights_KeepsUnknownTimeZonesPerProvider(t *testing.T) {
+	segment := entity.Segment{
+		DepartureAirport: "QQQ", DestinationAirport: "LIS",
+		DepartureTime: mustTime("2024-01-01T08:00:00Z"), ArrivalTime: mustTime("2024-01-01T09:40:00Z"),
+		MarketingCarrier: "TP", FlightNumber: "1017", TimeZoneUnknown: true,
+	}
+	flights := []entity.Flight{
+		{ProviderName: "Amadeus", Price: 120, Segments: []entity.Segment{segment}},
+		{ProviderName: "Amadeus", Price: 110, Segments: []entity.Segment{segment}},
+		{ProviderName: "duffel", Price: 100, Segments: []entity.Segment{segment}},
+	}
+
+	// the local clock of another provider may be a different instant
+	merged := dedupeFlights(flights)
+	require.Len(t, merged, 2)
+	assert.Equal(t, "Amadeus", merged[0].ProviderName)
+	assert.Equal(t, 110.0, merged[0].Price)
+	assert.Equal(t, "duffel", merged[1].ProviderName)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// MaxFlexibleDays limits the days of a flexible search, each day is a search in every provider
+	MaxFlexibleDays = 31
+
+	DefaultDateConcurrency = 3
+)
+
+// SetDateConcurrency changes how many days of a flexible search run at the same time
+func (s *FlightService) SetDateConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.dateConcurrency = n
+}
+
+// SearchFlexibleDates searches the route on every day of the range and returns
+// the cheapest price of each day and the best day to fly
+func (s *FlightService) SearchFlexibleDates(ctx context.Context, param entity.FlexibleSearchParam) (entity.FlexibleSearchResponse, error) {
+	return s.SearchFlexibleDatesProgress(ctx, param, nil)
+}
+
+// SearchFlexibleDatesProgress is SearchFlexibleDates calling onProgress after
+// each day is searched with the days done and the total, one call at a time
+func (s *FlightService) SearchFlexibleDatesProgress(ctx context.Context, param entity.FlexibleSearchParam, onProgress func(done, total int)) (entity.FlexibleSearchResponse, error) {
+	dates, err := flexibleDates(param, time.Now())
+	if err != nil {
+		return entity.FlexibleSearchResponse{}, err
+	}
+
+	calendar := make([]entity.DayPrice, len(dates))
+	sem := make(chan struct{}, s.dateConcurrency)
+
+	var progressMu sync.Mutex
+	done := 0
+	dayDone := func() {
+		if onProgress == nil {
+			return
+		}
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		done++
+		onProgress(done, len(dates))
+	}
+
+	var wg sync.WaitGroup
+	for i, date := range dates {
+		wg.Add(1)
+		go func(i int, date string) {
+			defer wg.Done()
+			calendar[i] = entity.DayPrice{Date: date}
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			resp := s.SearchFlights(ctx, entity.FlightSearchParam{
+				Origin:        param.Origin,
+				Destination:   param.Destination,
+				DateDeparture: date,
+			})
+			dayDone()
+			if len(resp.FlightByProvider) == 0 {
+				return
+			}
+
+			cheapest := resp.Cheapest
+			calendar[i].Available = true
+			calendar[i].Price = cheapest.Price
+			calendar[i].Cheapest = &cheapest
+		}(i, date)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return entity.FlexibleSearchResponse{}, err
+	}
+
+	resp := entity.FlexibleSearchResponse{
+		OriginName:      param.Origin,
+		DestinationName: param.Destination,
+		Calendar:        calendar,
+	}
+	for _, day := range calendar {
+		if day.Available && (resp.BestDate == "" || day.Price < resp.BestPrice) {
+			resp.BestDate = day.Date
+			resp.BestPrice = day.Price
+		}
+	}
+	return resp, nil
+}
+
+// flexibleDates returns the days to search, the days before today are skipped
+// because the providers don't have flights for them
+func flexibleDates(param entity.FlexibleSearchParam, now time.Time) ([]string, error) {
+	var from, to time.Time
+
+	if param.DateFrom != "" || param.DateTo != "" {
+		var err error
+		if from, err = time.Parse(dateLayout, param.DateFrom); err != nil {
+			return nil, fmt.Errorf("invalid date_from: %w", err)
+		}
+		if to, err = time.Parse(dateLayout, param.DateTo); err != nil {
+			return nil, fmt.Errorf("invalid date_to: %w", err)
+		}
+	} else {
+		date, err := time.Parse(dateLayout, param.DateDeparture)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date: %w", err)
+		}
+		from = date.AddDate(0, 0, -param.FlexDays)
+		to = date.AddDate(0, 0, param.FlexDays)
+	}
+
+	if to.Before(from) {
+		return nil, errors.New("the end of the date range is before the start")
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > MaxFlexibleDays {
+		return nil, fmt.Errorf("the date range has %d days, the maximum is %d", days, MaxFlexibleDays)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if from.Before(today) {
+		from = today
+	}
+
+	dates := make([]string, 0)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format(dateLayout))
+	}
+	if len(dates) == 0 {
+		return nil, errors.New("all the dates of the range are in the past")
+	}
+	return dates, nil
+}
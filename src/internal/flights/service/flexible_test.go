@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// priceByDateProvider returns one flight with the price configured for each date
+type priceByDateProvider struct {
+	prices map[string]float64
+	calls  atomic.Int32
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func (p *priceByDateProvider) SearchFlights(_ context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	p.calls.Add(1)
+
+	p.mu.Lock()
+	p.running++
+	p.maxRunning = max(p.maxRunning, p.running)
+	p.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+
+	price, exists := p.prices[criteria.DateDeparture]
+	if !exists {
+		return entity.FlightSearchResponse{}, assert.AnError
+	}
+	flight := entity.Flight{Price: price, DurationMinutes: 60}
+	return entity.FlightSearchResponse{
+		Provider: "stub",
+		Flights:  []entity.Flight{flight},
+		Cheapest: flight,
+		Fastest:  flight,
+	}, nil
+}
+
+func TestSearchFlexibleDates_CalendarAndBestDate(t *testing.T) {
+	provider := &priceByDateProvider{prices: map[string]float64{
+		"2030-05-09": 150,
+		"2030-05-10": 120,
+		"2030-05-11": 90,
+		"2030-05-12": 200,
+	}}
+	service := NewFlightService(provider)
+	service.SetDateConcurrency(2)
+
+	resp, err := service.SearchFlexibleDates(context.Background(), entity.FlexibleSearchParam{
+		Origin:        "Madrid",
+		Destination:   "Lisbon",
+		DateDeparture: "2030-05-10",
+		FlexDays:      2,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, resp.Calendar, 5)
+	assert.Equal(t, "2030-05-08", resp.Calendar[0].Date)
+	assert.False(t, resp.Calendar[0].Available)
+	assert.Equal(t, 120.0, resp.Calendar[2].Price)
+	assert.Equal(t, "2030-05-11", resp.BestDate)
+	assert.Equal(t, 90.0, resp.BestPrice)
+	assert.LessOrEqual(t, provider.maxRunning, 2)
+
+	// the second search reuses the cached days
+	calls := provider.calls.Load()
+	_, err = service.SearchFlexibleDates(context.Background(), entity.FlexibleSearchParam{
+		Origin:      "madrid",
+		Destination: "lisbon",
+		DateFrom:    "2030-05-09",
+		DateTo:      "2030-05-12",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, calls, provider.calls.Load())
+}
+
+func TestFlexibleDates(t *testing.T) {
+	now := time.Date(2030, 5, 10, 15, 0, 0, 0, time.UTC)
+
+	dates, err := flexibleDates(entity.FlexibleSearchParam{DateDeparture: "2030-05-11", FlexDays: 3}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2030-05-10", "2030-05-11", "2030-05-12", "2030-05-13", "2030-05-14"}, dates)
+
+	_, err = flexibleDates(entity.FlexibleSearchParam{DateFrom: "2030-06-10", DateTo: "2030-06-01"}, now)
+	assert.Error(t, err)
+
+	_, err = flexibleDates(entity.FlexibleSearchParam{DateFrom: "2030-06-01", DateTo: "2030-08-01"}, now)
+	assert.Error(t, err)
+
+	_, err = flexibleDates(entity.FlexibleSearchParam{DateFrom: "2030-01-01", DateTo: "2030-01-05"}, now)
+	assert.Error(t, err)
+}
+
+func TestPriceCalendar(t *testing.T) {
+	provider := &priceByDateProvider{prices: map[string]float64{
+		"2030-02-03": 80,
+		"2030-02-14": 140,
+		"2030-02-20": 95,
+	}}
+	service := NewFlightService(provider)
+
+	calendar, err := service.PriceCalendar(context.Background(), "Madrid", "Lisbon", "2030-02")
+	require.NoError(t, err)
+
+	require.Len(t, calendar.Days, 28)
+	assert.Equal(t, "2030-02-01", calendar.Days[0].Date)
+	assert.Nil(t, calendar.Days[2].Cheapest)
+	assert.Equal(t, 80.0, calendar.MinPrice)
+	assert.Equal(t, 140.0, calendar.MaxPrice)
+	assert.Equal(t, "2030-02-03", calendar.BestDate)
+
+	_, err = service.PriceCalendar(context.Background(), "Madrid", "Lisbon", "February")
+	require.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const (
+	// DefaultOfferTTL is how long the original offers of the searches are kept,
+	// the providers reject the offers that are too old anyway
+	DefaultOfferTTL = 30 * time.Minute
+	// maxStoredOffers bounds the memory used by the offers, the oldest are dropped first
+	maxStoredOffers = 5000
+)
+
+type offerEntry struct {
+	offer     entity.StoredOffer
+	expiresAt time.Time
+}
+
+// offerStore keeps the offers as the providers sent them, keyed by an opaque
+// token, so the pricing or a debugging session can recover them
+type offerStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]offerEntry
+	// order is the insertion order of the tokens
+	order []string
+}
+
+func newOfferStore(ttl time.Duration) *offerStore {
+	return &offerStore{
+		ttl:     ttl,
+		entries: make(map[string]offerEntry),
+	}
+}
+
+// add keeps the offer and returns its token, empty when the store is disabled
+func (s *offerStore) add(offer entity.StoredOffer) string {
+	if s.ttl <= 0 {
+		return ""
+	}
+	token := newOfferToken()
+	offer.Ref.Token = token
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for len(s.order) > 0 {
+		oldest, exists := s.entries[s.order[0]]
+		if exists && now.Before(oldest.expiresAt) && len(s.order) < maxStoredOffers {
+			break
+		}
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+
+	s.entries[token] = offerEntry{offer: offer, expiresAt: now.Add(s.ttl)}
+	s.order = append(s.order, token)
+	return token
+}
+
+func (s *offerStore) get(token string) (entity.StoredOffer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[token]
+	if !exists || time.Now().After(entry.expiresAt) {
+		return entity.StoredOffer{}, false
+	}
+	return entry.offer, true
+}
+
+func newOfferToken() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
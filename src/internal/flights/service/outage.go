@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/notify"
+)
+
+const (
+	DefaultOutageThreshold = 3
+
+	outageNotifyTimeout = time.Minute
+)
+
+// outageMonitor counts the consecutive failures of each provider, a provider
+// is down after threshold failures and up again with its first success
+type outageMonitor struct {
+	mu        sync.Mutex
+	threshold int
+	failures  map[string]int
+	down      map[string]bool
+	notifier  notify.Notifier
+}
+
+func newOutageMonitor(notifier notify.Notifier, threshold int) *outageMonitor {
+	if threshold <= 0 {
+		threshold = DefaultOutageThreshold
+	}
+	return &outageMonitor{
+		threshold: threshold,
+		failures:  make(map[string]int),
+		down:      make(map[string]bool),
+		notifier:  notifier,
+	}
+}
+
+// SetOutageNotifier sends a notification when a provider fails threshold
+// searches in a row and when it answers again
+func (s *FlightService) SetOutageNotifier(notifier notify.Notifier, threshold int) {
+	s.outages = newOutageMonitor(notifier, threshold)
+}
+
+// record updates the state of the provider and returns the message to send when it changes
+func (m *outageMonitor) record(provider string, err error) (notify.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		m.failures[provider] = 0
+		if !m.down[provider] {
+			return notify.Message{}, false
+		}
+		m.down[provider] = false
+		return notify.Message{
+			Event:     notify.EventProviderRecovered,
+			Subject:   fmt.Sprintf("Provider %s recovered", provider),
+			Text:      fmt.Sprintf("The provider %s is answering the searches again.", provider),
+			CreatedAt: time.Now(),
+		}, true
+	}
+
+	m.failures[provider]++
+	if m.down[provider] || m.failures[provider] < m.threshold {
+		return notify.Message{}, false
+	}
+	m.down[provider] = true
+	return notify.Message{
+		Event:     notify.EventProviderOutage,
+		Subject:   fmt.Sprintf("Provider %s is failing", provider),
+		Text:      fmt.Sprintf("The provider %s failed %d searches in a row, last error: %v", provider, m.failures[provider], err),
+		CreatedAt: time.Now(),
+	}, true
+}
+
+// observe records the result of a provider search, the notification is sent in
+// the background so the retries of the channel don't delay the search
+func (m *outageMonitor) observe(provider string, err error) {
+	if m == nil {
+		return
+	}
+
+	msg, changed := m.record(provider, err)
+	if !changed {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), outageNotifyTimeout)
+		defer cancel()
+		if err := m.notifier.Send(ctx, msg); err != nil {
+			log.Printf("error sending provider outage notification: %v", err)
+		}
+	}()
+}
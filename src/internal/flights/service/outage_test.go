@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutageMonitor(t *testing.T) {
+	m := newOutageMonitor(notify.Log{}, 2)
+	failure := errors.New("status code 503")
+
+	_, changed := m.record("sky", failure)
+	assert.False(t, changed)
+
+	msg, changed := m.record("sky", failure)
+	require.True(t, changed)
+	assert.Equal(t, notify.EventProviderOutage, msg.Event)
+	assert.Contains(t, msg.Text, "status code 503")
+
+	// only one notification while the provider is down
+	_, changed = m.record("sky", failure)
+	assert.False(t, changed)
+	_, changed = m.record("google", nil)
+	assert.False(t, changed)
+
+	msg, changed = m.record("sky", nil)
+	require.True(t, changed)
+	assert.Equal(t, notify.EventProviderRecovered, msg.Event)
+
+	// a success resets the consecutive failures
+	_, changed = m.record("sky", failure)
+	assert.False(t, changed)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+)
+
+// SetOfferTTL changes how long the original offers are kept, zero doesn't keep them
+func (s *FlightService) SetOfferTTL(ttl time.Duration) {
+	s.offers = newOfferStore(ttl)
+}
+
+// keepOffers sets the reference of the provider in the flights of its response
+// and keeps their original offers, the raw offers are not sent to the clients
+func (s *FlightService) keepOffers(resp *entity.FlightSearchResponse, name string, priceable bool) {
+	// the cheapest and the fastest are copies of flights of the list
+	tokens := make(map[string]string, len(resp.Flights))
+
+	keep := func(f *entity.Flight) {
+		if f.Ref == nil && f.Raw == nil {
+			return
+		}
+
+		ref := entity.ProviderRef{Provider: name}
+		if f.Ref != nil {
+			ref.ID = f.Ref.ID
+		}
+		if f.Raw != nil {
+			token, exists := tokens[string(f.Raw)]
+			if !exists {
+				// only the stored offers can be priced
+				stored := ref
+				stored.Priceable = priceable
+				token = s.offers.add(entity.StoredOffer{Ref: stored, Price: f.Price, Raw: f.Raw})
+				tokens[string(f.Raw)] = token
+			}
+			ref.Token = token
+			ref.Priceable = priceable && token != ""
+		}
+
+		f.Ref = &ref
+		f.Raw = nil
+	}
+
+	for i := range resp.Flights {
+		keep(&resp.Flights[i])
+	}
+	keep(&resp.Cheapest)
+	keep(&resp.Fastest)
+}
+
+// Offer returns the original offer of the token of a flight
+func (s *FlightService) Offer(token string) (entity.StoredOffer, error) {
+	offer, exists := s.offers.get(token)
+	if !exists {
+		return entity.StoredOffer{}, providers.ErrOfferNotFound
+	}
+	return offer, nil
+}
+
+// PriceOffer confirms the current price of the offer of the token in the provider
+// that offered it, the providers without pricing return ErrPricingNotSupported
+func (s *FlightService) PriceOffer(ctx context.Context, token string) (entity.PricedOffer, error) {
+	offer, err := s.Offer(token)
+	if err != nil {
+		return entity.PricedOffer{}, err
+	}
+
+	for _, p := range s.providers {
+		if providerName(p, entity.FlightSearchResponse{}) != offer.Ref.Provider {
+			continue
+		}
+
+		pricer, ok := p.(providers.Pricer)
+		if !ok {
+			break
+		}
+		return pricer.PriceOffer(ctx, offer)
+	}
+	return entity.PricedOffer{}, fmt.Errorf("%w: %s", providers.ErrPricingNotSupported, offer.Ref.Provider)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pricingProvider sends its offers with their id and their original JSON
+type pricingProvider struct {
+	delayedProvider
+}
+
+func (p *pricingProvider) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	resp, err := p.delayedProvider.SearchFlights(ctx, criteria)
+	for _, f := range []*entity.Flight{&resp.Flights[0], &resp.Cheapest, &resp.Fastest} {
+		f.Ref = &entity.ProviderRef{ID: "offer-1"}
+		f.Raw = json.RawMessage(`{"id": "offer-1", "fare": "basic"}`)
+	}
+	return resp, err
+}
+
+func (p *pricingProvider) PriceOffer(_ context.Context, offer entity.StoredOffer) (entity.PricedOffer, error) {
+	return entity.PricedOffer{Provider: p.name, Token: offer.Ref.Token, PreviousPrice: offer.Price, Price: offer.Price + 10, PriceChange: 10}, nil
+}
+
+func TestPriceOffer(t *testing.T) {
+	service := NewFlightService(
+		providers.NewRateLimited(&delayedProvider{name: "search-only", price: 100}, 0),
+		providers.NewRateLimited(&pricingProvider{delayedProvider{name: "pricing", price: 90}}, 0),
+	)
+	ctx := context.Background()
+
+	resp := service.SearchFlights(ctx, entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10"})
+	require.Len(t, resp.FlightByProvider, 2)
+
+	var ref *entity.ProviderRef
+	for _, r := range resp.FlightByProvider {
+		if r.Provider == "pricing" {
+			ref = r.Flights[0].Ref
+			// the cheapest is the same offer, it has the same token
+			assert.Equal(t, ref, r.Cheapest.Ref)
+			assert.Nil(t, r.Flights[0].Raw)
+		} else {
+			assert.Nil(t, r.Flights[0].Ref)
+		}
+	}
+	require.NotNil(t, ref)
+	assert.Equal(t, "pricing", ref.Provider)
+	assert.Equal(t, "offer-1", ref.ID)
+	assert.True(t, ref.Priceable)
+	require.NotEmpty(t, ref.Token)
+
+	offer, err := service.Offer(ref.Token)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": "offer-1", "fare": "basic"}`, string(offer.Raw))
+	assert.Equal(t, 90.0, offer.Price)
+
+	priced, err := service.PriceOffer(ctx, ref.Token)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, priced.Price)
+	assert.Equal(t, ref.Token, priced.Token)
+
+	_, err = service.PriceOffer(ctx, "unknown")
+	assert.ErrorIs(t, err, providers.ErrOfferNotFound)
+}
+
+func TestPriceOffer_ProviderWithoutPricing(t *testing.T) {
+	service := NewFlightService(&delayedProvider{name: "search-only", price: 100})
+
+	token := service.offers.add(entity.StoredOffer{Ref: entity.ProviderRef{Provider: "search-only"}, Raw: json.RawMessage(`{}`)})
+	_, err := service.PriceOffer(context.Background(), token)
+	assert.ErrorIs(t, err, providers.ErrPricingNotSupported)
+}
+
+func TestKeepOffers_Disabled(t *testing.T) {
+	service := NewFlightService(&pricingProvider{delayedProvider{name: "pricing", price: 90}})
+	service.SetOfferTTL(0)
+
+	resp := service.SearchFlights(context.Background(), entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10"})
+	require.Len(t, resp.Flights, 1)
+	// the id of the provider is kept without the offer, it can't be priced
+	assert.Equal(t, &entity.ProviderRef{Provider: "pricing", ID: "offer-1"}, resp.Flights[0].Ref)
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/flights/ranking"
+)
+
+const clockLayout = "15:04"
+
+// ApplyQuery filters, sorts and paginates the merged flight list of the response
+func ApplyQuery(resp entity.FlightPriceResponse, query entity.FlightQuery) (entity.FlightPriceResponse, error) {
+	flights, err := filterFlights(resp.Flights, query)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Rankings, err = rankFlights(flights, query)
+	if err != nil {
+		return resp, err
+	}
+	if best := resp.Rankings[ranking.Best]; len(best) > 0 {
+		resp.Best = best[0]
+	}
+
+	if err := sortFlights(flights, query); err != nil {
+		return resp, err
+	}
+
+	resp.Flights, resp.Pagination = paginate(flights, query.Page, query.PageSize)
+	return resp, nil
+}
+
+// FilteredFlights returns all the flights of the response that match the
+// filters of the query in its order, without pagination
+func FilteredFlights(resp entity.FlightPriceResponse, query entity.FlightQuery) ([]entity.Flight, error) {
+	flights, err := filterFlights(resp.Flights, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := sortFlights(flights, query); err != nil {
+		return nil, err
+	}
+	return flights, nil
+}
+
+func filterFlights(all []entity.Flight, query entity.FlightQuery) ([]entity.Flight, error) {
+	filter, err := newFlightFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	flights := make([]entity.Flight, 0, len(all))
+	for _, f := range all {
+		if query.CheckedBag {
+			// the fare shown is the one with the bag, also for the price filter
+			f = ranking.BagFare(f)
+		}
+		if filter.match(f) {
+			flights = append(flights, f)
+		}
+	}
+	return flights, nil
+}
+
+// rankFlights returns the top flights of every ranking criterion
+func rankFlights(flights []entity.Flight, query entity.FlightQuery) (map[string][]entity.Flight, error) {
+	topN := query.TopN
+	if topN <= 0 {
+		topN = entity.DefaultTopN
+	}
+	if topN > entity.MaxTopN {
+		topN = entity.MaxTopN
+	}
+
+	rankings := make(map[string][]entity.Flight)
+	for _, criterion := range ranking.Criteria() {
+		top, err := ranking.Top(flights, criterion, query.Weights, topN)
+		if err != nil {
+			return nil, err
+		}
+		rankings[criterion] = top
+	}
+	return rankings, nil
+}
+
+type clockWindow struct {
+	from, to time.Duration
+	enabled  bool
+}
+
+// contains reports if the time of day of t, in its own time zone, is inside
+// the window. A window where from is after to wraps midnight, e.g. 22:00-06:00
+func (w clockWindow) contains(t time.Time) bool {
+	if !w.enabled {
+		return true
+	}
+
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if w.from <= w.to {
+		return clock >= w.from && clock <= w.to
+	}
+	return clock >= w.from || clock <= w.to
+}
+
+type flightFilter struct {
+	maxStops    int
+	maxPrice    float64
+	maxDuration int
+	checkedBag  bool
+	airlines    map[string]bool
+	departure   clockWindow
+	arrival     clockWindow
+}
+
+func newFlightFilter(query entity.FlightQuery) (flightFilter, error) {
+	departure, err := newClockWindow(query.DepartureAfter, query.DepartureBefore)
+	if err != nil {
+		return flightFilter{}, fmt.Errorf("invalid departure window: %w", err)
+	}
+	arrival, err := newClockWindow(query.ArrivalAfter, query.ArrivalBefore)
+	if err != nil {
+		return flightFilter{}, fmt.Errorf("invalid arrival window: %w", err)
+	}
+
+	airlines := make(map[string]bool)
+	for _, value := range query.Airlines {
+		// accept both repeated params and comma separated lists
+		for _, code := range strings.Split(value, ",") {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if code != "" {
+				airlines[code] = true
+			}
+		}
+	}
+
+	return flightFilter{
+		maxStops:    query.MaxStops,
+		maxPrice:    query.MaxPrice,
+		maxDuration: query.MaxDurationMinutes,
+		checkedBag:  query.CheckedBag,
+		airlines:    airlines,
+		departure:   departure,
+		arrival:     arrival,
+	}, nil
+}
+
+func newClockWindow(after, before string) (clockWindow, error) {
+	if after == "" && before == "" {
+		return clockWindow{}, nil
+	}
+
+	w := clockWindow{enabled: true, to: 24*time.Hour - time.Minute}
+	if after != "" {
+		t, err := time.Parse(clockLayout, after)
+		if err != nil {
+			return clockWindow{}, err
+		}
+		w.from = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if before != "" {
+		t, err := time.Parse(clockLayout, before)
+		if err != nil {
+			return clockWindow{}, err
+		}
+		w.to = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return w, nil
+}
+
+func (ff flightFilter) match(f entity.Flight) bool {
+	if ff.maxStops >= 0 && f.Stops > ff.maxStops {
+		return false
+	}
+	if ff.maxPrice > 0 && f.Price > ff.maxPrice {
+		return false
+	}
+	if ff.maxDuration > 0 && f.DurationMinutes > ff.maxDuration {
+		return false
+	}
+	if ff.checkedBag && !ranking.IncludesBag(f) {
+		return false
+	}
+
+	if len(ff.airlines) > 0 {
+		if len(f.Segments) == 0 {
+			return false
+		}
+		for _, s := range f.Segments {
+			if !ff.airlines[strings.ToUpper(s.MarketingCarrier)] {
+				return false
+			}
+		}
+	}
+
+	if ff.departure.enabled {
+		dep, ok := departureTime(f)
+		if !ok || !ff.departure.contains(dep) {
+			return false
+		}
+	}
+	if ff.arrival.enabled {
+		arr, ok := arrivalTime(f)
+		if !ok || !ff.arrival.contains(arr) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortFlights(flights []entity.Flight, query entity.FlightQuery) error {
+	desc := query.Order == entity.SortOrderDesc
+
+	if query.SortBy == entity.SortByBest {
+		if err := ranking.Sort(flights, ranking.Best, query.Weights); err != nil {
+			return err
+		}
+		if desc {
+			slices.Reverse(flights)
+		}
+		return nil
+	}
+
+	less := func(a, b entity.Flight) bool { return a.Price < b.Price }
+
+	switch query.SortBy {
+	case entity.SortByDuration:
+		less = func(a, b entity.Flight) bool { return a.DurationMinutes < b.DurationMinutes }
+	case entity.SortByStops:
+		less = func(a, b entity.Flight) bool { return a.Stops < b.Stops }
+	case entity.SortByPriceWithBag:
+		bagPrice := query.Weights.CheckedBagPrice
+		less = func(a, b entity.Flight) bool {
+			return ranking.PriceWithBag(a, bagPrice) < ranking.PriceWithBag(b, bagPrice)
+		}
+	case entity.SortByDeparture:
+		less = func(a, b entity.Flight) bool {
+			depA, okA := departureTime(a)
+			depB, okB := departureTime(b)
+			if okA != okB {
+				// flights without a known departure go last
+				return okA
+			}
+			return depA.Before(depB)
+		}
+	}
+
+	sort.SliceStable(flights, func(i, j int) bool {
+		if desc {
+			return less(flights[j], flights[i])
+		}
+		return less(flights[i], flights[j])
+	})
+	return nil
+}
+
+func paginate(flights []entity.Flight, page, pageSize int) ([]entity.Flight, entity.Pagination) {
+	if pageSize <= 0 {
+		pageSize = entity.DefaultPageSize
+	}
+	if pageSize > entity.MaxPageSize {
+		pageSize = entity.MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	p := entity.Pagination{
+		Page:         page,
+		PageSize:     pageSize,
+		TotalFlights: len(flights),
+		TotalPages:   (len(flights) + pageSize - 1) / pageSize,
+	}
+
+	// compare the pages before multiplying, a huge page would overflow the start
+	if page > p.TotalPages {
+		return []entity.Flight{}, p
+	}
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if end > len(flights) {
+		end = len(flights)
+	}
+	return flights[start:end], p
+}
+
+func departureTime(f entity.Flight) (time.Time, bool) {
+	if len(f.Segments) == 0 || f.Segments[0].DepartureTime.IsZero() {
+		return time.Time{}, false
+	}
+	return f.Segments[0].DepartureTime, true
+}
+
+func arrivalTime(f entity.Flight) (time.Time, bool) {
+	if len(f.Segments) == 0 || f.Segments[len(f.Segments)-1].ArrivalTime.IsZero() {
+		return time.Time{}, false
+	}
+	return f.Segments[len(f.Segments)-1].ArrivalTime, true
+}
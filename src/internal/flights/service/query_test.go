@@ -0,0 +1,189 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/flights/ranking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testFlights() []entity.Flight {
+	return []entity.Flight{
+		{
+			ProviderName:    "a",
+			Price:           300,
+			DurationMinutes: 120,
+			CheckedBags:     &entity.Baggage{Quantity: 1},
+			Segments: []entity.Segment{
+				{MarketingCarrier: "IB", DepartureTime: mustTime("2024-01-01T08:00:00Z"), ArrivalTime: mustTime("2024-01-01T10:00:00Z")},
+			},
+		},
+		{
+			ProviderName:    "b",
+			Price:           150,
+			DurationMinutes: 300,
+			Stops:           1,
+			Segments: []entity.Segment{
+				{MarketingCarrier: "TP", DepartureTime: mustTime("2024-01-01T06:00:00Z"), ArrivalTime: mustTime("2024-01-01T08:00:00Z")},
+				{MarketingCarrier: "TP", DepartureTime: mustTime("2024-01-01T09:00:00Z"), ArrivalTime: mustTime("2024-01-01T11:00:00Z")},
+			},
+		},
+		{
+			ProviderName:    "c",
+			Price:           200,
+			DurationMinutes: 90,
+			CheckedBags:     &entity.Baggage{},
+			Segments: []entity.Segment{
+				{MarketingCarrier: "UX", DepartureTime: mustTime("2024-01-01T22:30:00Z"), ArrivalTime: mustTime("2024-01-02T00:00:00Z")},
+			},
+		},
+	}
+}
+
+func TestApplyQuery_DefaultSortsByPrice(t *testing.T) {
+	resp, err := ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, entity.DefaultFlightQuery())
+	require.NoError(t, err)
+
+	require.Len(t, resp.Flights, 3)
+	assert.Equal(t, []float64{150, 200, 300}, []float64{resp.Flights[0].Price, resp.Flights[1].Price, resp.Flights[2].Price})
+	assert.Equal(t, entity.Pagination{Page: 1, PageSize: entity.DefaultPageSize, TotalFlights: 3, TotalPages: 1}, resp.Pagination)
+}
+
+func TestApplyQuery_SortDepartureDesc(t *testing.T) {
+	query := entity.DefaultFlightQuery()
+	query.SortBy = entity.SortByDeparture
+	query.Order = entity.SortOrderDesc
+
+	resp, err := ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.NoError(t, err)
+	assert.Equal(t, "c", resp.Flights[0].ProviderName)
+	assert.Equal(t, "b", resp.Flights[2].ProviderName)
+}
+
+func TestApplyQuery_SortPriceWithBag(t *testing.T) {
+	query := entity.DefaultFlightQuery()
+	query.SortBy = entity.SortByPriceWithBag
+
+	resp, err := ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.NoError(t, err)
+	assert.Equal(t, "b", resp.Flights[0].ProviderName)
+	assert.Equal(t, "c", resp.Flights[1].ProviderName)
+
+	// with an expensive bag the fare that includes one is the cheapest
+	query.Weights.CheckedBagPrice = 200
+	resp, err = ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.NoError(t, err)
+	assert.Equal(t, "a", resp.Flights[0].ProviderName)
+	assert.Equal(t, "a", resp.Rankings[ranking.CheapestWithBag][0].ProviderName)
+}
+
+func TestApplyQuery_Filters(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(q *entity.FlightQuery)
+		expected []string
+	}{
+		{"nonstop", func(q *entity.FlightQuery) { q.MaxStops = 0 }, []string{"c", "a"}},
+		{"max price", func(q *entity.FlightQuery) { q.MaxPrice = 200 }, []string{"b", "c"}},
+		{"max duration", func(q *entity.FlightQuery) { q.MaxDurationMinutes = 120 }, []string{"c", "a"}},
+		{"checked bag", func(q *entity.FlightQuery) { q.CheckedBag = true }, []string{"a"}},
+		{"airlines", func(q *entity.FlightQuery) { q.Airlines = []string{"tp, ib"} }, []string{"b", "a"}},
+		{"departure window", func(q *entity.FlightQuery) { q.DepartureAfter = "07:00"; q.DepartureBefore = "12:00" }, []string{"a"}},
+		{"overnight arrival window", func(q *entity.FlightQuery) { q.ArrivalAfter = "23:00"; q.ArrivalBefore = "10:30" }, []string{"c", "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := entity.DefaultFlightQuery()
+			tt.modify(&query)
+
+			resp, err := ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(resp.Flights))
+			for _, f := range resp.Flights {
+				names = append(names, f.ProviderName)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestApplyQuery_CheckedBagInAnotherOffer(t *testing.T) {
+	flights := testFlights()
+	// provider b has no bag, another provider sells the same flight with one
+	flights[1].Offers = []entity.ProviderOffer{
+		{Provider: "b", Price: 150},
+		{Provider: "d", Price: 170, CheckedBags: &entity.Baggage{Quantity: 1}},
+	}
+
+	query := entity.DefaultFlightQuery()
+	query.CheckedBag = true
+	resp, err := ApplyQuery(entity.FlightPriceResponse{Flights: flights}, query)
+	require.NoError(t, err)
+	require.Len(t, resp.Flights, 2)
+	// the flight shows the fare of d, the one with the bag
+	assert.Equal(t, "d", resp.Flights[0].ProviderName)
+	assert.Equal(t, 170.0, resp.Flights[0].Price)
+	assert.True(t, resp.Flights[0].CheckedBags.Included())
+	assert.Equal(t, "a", resp.Flights[1].ProviderName)
+
+	// the price filter applies to the fare with the bag
+	query.MaxPrice = 160
+	resp, err = ApplyQuery(entity.FlightPriceResponse{Flights: flights}, query)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Flights)
+}
+
+func TestApplyQuery_Pagination(t *testing.T) {
+	query := entity.DefaultFlightQuery()
+	query.PageSize = 2
+	query.Page = 2
+
+	resp, err := ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.NoError(t, err)
+	require.Len(t, resp.Flights, 1)
+	assert.Equal(t, float64(300), resp.Flights[0].Price)
+	assert.Equal(t, 2, resp.Pagination.TotalPages)
+
+	query.Page = 5
+	resp, err = ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Flights)
+
+	query.Page = math.MaxInt64/2 + 1
+	resp, err = ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Flights)
+}
+
+func TestApplyQuery_InvalidWindow(t *testing.T) {
+	query := entity.DefaultFlightQuery()
+	query.DepartureAfter = "8am"
+
+	_, err := ApplyQuery(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.Error(t, err)
+}
+
+func TestFilteredFlights_WithoutPagination(t *testing.T) {
+	query := entity.DefaultFlightQuery()
+	query.PageSize = 1
+	query.MaxPrice = 250
+
+	flights, err := FilteredFlights(entity.FlightPriceResponse{Flights: testFlights()}, query)
+	require.NoError(t, err)
+	require.Len(t, flights, 2)
+	assert.Equal(t, []float64{150, 200}, []float64{flights[0].Price, flights[1].Price})
+}
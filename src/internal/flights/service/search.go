@@ -2,29 +2,102 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/flights/ranking"
 	"github.com/mariajdab/flight-price/internal/providers"
 )
 
-const (
-	criteriaCheapest = "cheapest"
-	criteriaFastest  = "fastest"
-)
-
 type FlightService struct {
-	providers []providers.Flight
+	providers       []providers.Flight
+	cache           *searchCache
+	dateConcurrency int
+	outages         *outageMonitor
+	prices          PriceStore
+	offers          *offerStore
+}
+
+// PriceStore keeps the prices found by the searches, implemented by history.Repository
+type PriceStore interface {
+	AddPrices(ctx context.Context, records []entity.PriceRecord) error
 }
 
 func NewFlightService(providers ...providers.Flight) *FlightService {
 	return &FlightService{
-		providers: providers,
+		providers:       providers,
+		cache:           newSearchCache(DefaultCacheTTL),
+		offers:          newOfferStore(DefaultOfferTTL),
+		dateConcurrency: DefaultDateConcurrency,
 	}
 }
 
+// SetCacheTTL changes how long the search results are reused, zero disables the cache
+func (s *FlightService) SetCacheTTL(ttl time.Duration) {
+	s.cache = newSearchCache(ttl)
+}
+
 func (s *FlightService) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) entity.FlightPriceResponse {
+	return s.SearchFlightsStream(ctx, criteria, nil)
+}
+
+// SearchFlightsStream is SearchFlights calling onUpdate as soon as each provider
+// answers, so the fast providers can be shown before the slow ones finish.
+// A cached search calls onUpdate once for each provider of the cached response
+func (s *FlightService) SearchFlightsStream(ctx context.Context, criteria entity.FlightSearchParam, onUpdate func(entity.SearchUpdate)) entity.FlightPriceResponse {
+	if resp, ok := s.cache.get(criteria); ok {
+		if onUpdate != nil {
+			replayUpdates(resp, onUpdate)
+		}
+		return resp
+	}
+
+	resp := s.searchProviders(ctx, criteria, onUpdate)
+	s.storePrices(ctx, criteria, resp)
+
+	// a search without results is not cached, the providers could be failing temporarily
+	if len(resp.FlightByProvider) > 0 {
+		s.cache.set(criteria, resp)
+	}
+	return resp
+}
+
+// SetPriceStore records the cheapest price of each provider every time the providers are searched
+func (s *FlightService) SetPriceStore(store PriceStore) {
+	s.prices = store
+}
+
+func (s *FlightService) storePrices(ctx context.Context, criteria entity.FlightSearchParam, resp entity.FlightPriceResponse) {
+	if s.prices == nil || len(resp.FlightByProvider) == 0 {
+		return
+	}
+
+	now := time.Now()
+	records := make([]entity.PriceRecord, 0, len(resp.FlightByProvider))
+	for _, r := range resp.FlightByProvider {
+		if r.Cheapest.Price <= 0 {
+			continue
+		}
+		records = append(records, entity.PriceRecord{
+			Origin:        criteria.Origin,
+			Destination:   criteria.Destination,
+			DateDeparture: criteria.DateDeparture,
+			Provider:      r.Provider,
+			Price:         r.Cheapest.Price,
+			ObservedAt:    now,
+		})
+	}
+
+	// the prices are stored even if the user cancels the request after the search
+	if err := s.prices.AddPrices(context.WithoutCancel(ctx), records); err != nil {
+		log.Printf("error storing the price history: %v", err)
+	}
+}
+
+func (s *FlightService) searchProviders(ctx context.Context, criteria entity.FlightSearchParam, onUpdate func(entity.SearchUpdate)) entity.FlightPriceResponse {
 	var wg sync.WaitGroup
 
 	allCheapest := make([]entity.Flight, 0, len(s.providers))
@@ -42,11 +115,19 @@ func (s *FlightService) SearchFlights(ctx context.Context, criteria entity.Fligh
 		go func(p providers.Flight) {
 			defer wg.Done()
 			resp, err := p.SearchFlights(ctx, criteria)
+			name := providerName(p, resp)
+			if err == nil {
+				s.keepOffers(&resp, name, providers.CanPrice(p))
+			}
+			// a cancelled search says nothing about the provider
+			if ctx.Err() == nil {
+				s.outages.observe(name, err)
+			}
 			resultChan <- struct {
 				resp         entity.FlightSearchResponse
 				providerName string
 				err          error
-			}{resp, resp.Provider, err}
+			}{resp, name, err}
 		}(provider)
 	}
 
@@ -55,58 +136,119 @@ func (s *FlightService) SearchFlights(ctx context.Context, criteria entity.Fligh
 		close(resultChan)
 	}()
 
+	done := 0
 	for result := range resultChan {
+		done++
 		if result.err != nil {
 			log.Printf("provider %s get error in SearchFlights: %v", result.providerName, result.err)
-			continue
+		} else {
+			allCheapest = append(allCheapest, result.resp.Cheapest)
+			allFastest = append(allFastest, result.resp.Fastest)
+			allProviderFlights = append(allProviderFlights, result.resp)
 		}
 
-		allCheapest = append(allCheapest, result.resp.Cheapest)
-		allFastest = append(allFastest, result.resp.Fastest)
-		allProviderFlights = append(allProviderFlights, result.resp)
+		if onUpdate != nil {
+			onUpdate(entity.SearchUpdate{
+				Provider:       result.providerName,
+				Failed:         result.err != nil,
+				Flights:        providerFlights(result.resp, result.providerName),
+				Cheapest:       getGlobalBestFlight(allCheapest, ranking.Cheapest),
+				Fastest:        getGlobalBestFlight(allFastest, ranking.Fastest),
+				ProvidersDone:  done,
+				ProvidersTotal: len(s.providers),
+			})
+		}
 	}
 
 	if len(allCheapest) == 0 {
 		return entity.FlightPriceResponse{}
 	}
 
-	cheapest := getGlobalBestFlight(allCheapest, criteriaCheapest)
-	fastest := getGlobalBestFlight(allFastest, criteriaFastest)
+	cheapest := getGlobalBestFlight(allCheapest, ranking.Cheapest)
+	fastest := getGlobalBestFlight(allFastest, ranking.Fastest)
 
 	return entity.FlightPriceResponse{
 		OriginName:       criteria.Origin,
 		DestinationName:  criteria.Destination,
 		Cheapest:         cheapest,
 		Fastest:          fastest,
+		Flights:          dedupeFlights(mergeProviderFlights(allProviderFlights)),
 		FlightByProvider: allProviderFlights,
 	}
 }
 
-func getGlobalBestFlight(flights []entity.Flight, criteria string) entity.Flight {
-	if len(flights) == 0 {
-		return entity.Flight{}
+// replayUpdates sends the updates of a cached response as if the providers answered again
+func replayUpdates(resp entity.FlightPriceResponse, onUpdate func(entity.SearchUpdate)) {
+	allCheapest := make([]entity.Flight, 0, len(resp.FlightByProvider))
+	allFastest := make([]entity.Flight, 0, len(resp.FlightByProvider))
+
+	for i, r := range resp.FlightByProvider {
+		allCheapest = append(allCheapest, r.Cheapest)
+		allFastest = append(allFastest, r.Fastest)
+		onUpdate(entity.SearchUpdate{
+			Provider:       r.Provider,
+			Flights:        providerFlights(r, r.Provider),
+			Cheapest:       getGlobalBestFlight(allCheapest, ranking.Cheapest),
+			Fastest:        getGlobalBestFlight(allFastest, ranking.Fastest),
+			ProvidersDone:  i + 1,
+			ProvidersTotal: len(resp.FlightByProvider),
+		})
 	}
+}
 
-	bestFlight := flights[0]
-
-	switch criteria {
-	case criteriaCheapest:
-		for _, f := range flights[1:] {
-			if f.Price < bestFlight.Price {
-				bestFlight = f
-			}
+// providerFlights returns the flights of a provider response with the name of the provider set
+func providerFlights(resp entity.FlightSearchResponse, name string) []entity.Flight {
+	flights := make([]entity.Flight, 0, len(resp.Flights))
+	for _, f := range resp.Flights {
+		if f.ProviderName == "" {
+			f.ProviderName = name
 		}
-	case criteriaFastest:
-		bestDuration := bestFlight.DurationMinutes
-		for _, f := range flights[1:] {
-			if f.DurationMinutes < bestDuration {
-				bestFlight = f
-				bestDuration = f.DurationMinutes
+		flights = append(flights, f)
+	}
+	return flights
+}
+
+// providerName identifies the provider, the response has no name when the search fails
+func providerName(p providers.Flight, resp entity.FlightSearchResponse) string {
+	if named, ok := p.(providers.Named); ok {
+		return named.Name()
+	}
+	if resp.Provider != "" {
+		return resp.Provider
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// mergeProviderFlights joins the flights of every provider in a single list,
+// keeping the name of the provider that offers each one
+func mergeProviderFlights(responses []entity.FlightSearchResponse) []entity.Flight {
+	total := 0
+	for _, r := range responses {
+		total += len(r.Flights)
+	}
+
+	flights := make([]entity.Flight, 0, total)
+	for _, r := range responses {
+		for _, f := range r.Flights {
+			if f.ProviderName == "" {
+				f.ProviderName = r.Provider
 			}
+			flights = append(flights, f)
 		}
-	default:
+	}
+	return flights
+}
+
+func getGlobalBestFlight(flights []entity.Flight, criteria string) entity.Flight {
+	if len(flights) == 0 {
+		return entity.Flight{}
+	}
+
+	top, err := ranking.Top(flights, criteria, entity.DefaultRankingWeights(), 1)
+	if err != nil {
 		log.Printf("invalid criteria: %s", criteria)
+		return flights[0]
 	}
 
-	return bestFlight
+	return top[0]
 }
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPriceStore struct {
+	records []entity.PriceRecord
+}
+
+func (s *recordingPriceStore) AddPrices(_ context.Context, records []entity.PriceRecord) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func TestSearchFlights_StoresPricesOfProviderSearches(t *testing.T) {
+	store := &recordingPriceStore{}
+	service := NewFlightService(&priceByDateProvider{prices: map[string]float64{"2030-05-10": 120}})
+	service.SetPriceStore(store)
+
+	criteria := entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10"}
+	service.SearchFlights(context.Background(), criteria)
+	// the second search comes from the cache, the price was already stored
+	service.SearchFlights(context.Background(), criteria)
+	// no provider answered, nothing to store
+	service.SearchFlights(context.Background(), entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-11"})
+
+	require.Len(t, store.records, 1)
+	assert.Equal(t, "stub", store.records[0].Provider)
+	assert.Equal(t, 120.0, store.records[0].Price)
+	assert.Equal(t, "2030-05-10", store.records[0].DateDeparture)
+	assert.False(t, store.records[0].ObservedAt.IsZero())
+}
+
+// delayedProvider answers with one flight after the delay
+type delayedProvider struct {
+	name  string
+	price float64
+	delay time.Duration
+	err   error
+}
+
+func (p *delayedProvider) Name() string {
+	return p.name
+}
+
+func (p *delayedProvider) SearchFlights(context.Context, entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	time.Sleep(p.delay)
+	if p.err != nil {
+		return entity.FlightSearchResponse{}, p.err
+	}
+	flight := entity.Flight{Price: p.price, DurationMinutes: int(p.price)}
+	return entity.FlightSearchResponse{
+		Provider: p.name,
+		Flights:  []entity.Flight{flight},
+		Cheapest: flight,
+		Fastest:  flight,
+	}, nil
+}
+
+func TestSearchFlightsStream_UpdatesAsProvidersAnswer(t *testing.T) {
+	service := NewFlightService(
+		&delayedProvider{name: "slow", price: 100, delay: 40 * time.Millisecond},
+		&delayedProvider{name: "fast", price: 150},
+		&delayedProvider{name: "broken", delay: 20 * time.Millisecond, err: assert.AnError},
+	)
+	criteria := entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10"}
+
+	var updates []entity.SearchUpdate
+	resp := service.SearchFlightsStream(context.Background(), criteria, func(u entity.SearchUpdate) {
+		updates = append(updates, u)
+	})
+
+	require.Len(t, updates, 3)
+	assert.Equal(t, []string{"fast", "broken", "slow"}, []string{updates[0].Provider, updates[1].Provider, updates[2].Provider})
+	assert.Equal(t, 150.0, updates[0].Cheapest.Price)
+	assert.Equal(t, "fast", updates[0].Flights[0].ProviderName)
+	assert.True(t, updates[1].Failed)
+	assert.Equal(t, 100.0, updates[2].Cheapest.Price)
+	assert.Equal(t, 3, updates[2].ProvidersDone)
+	assert.Equal(t, 3, updates[2].ProvidersTotal)
+	assert.Equal(t, 100.0, resp.Cheapest.Price)
+
+	// the cached search sends the same results again
+	updates = nil
+	service.SearchFlightsStream(context.Background(), criteria, func(u entity.SearchUpdate) {
+		updates = append(updates, u)
+	})
+	require.Len(t, updates, 2)
+	assert.Equal(t, 100.0, updates[1].Cheapest.Price)
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func record(provider, date string, price float64, observedAt time.Time) entity.PriceRecord {
+	return entity.PriceRecord{
+		Origin:        "Madrid",
+		Destination:   "Lisbon",
+		DateDeparture: date,
+		Provider:      provider,
+		Price:         price,
+		ObservedAt:    observedAt,
+	}
+}
+
+func TestSQLiteRepository_Prices(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+
+	day := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.AddPrices(ctx, []entity.PriceRecord{
+		record("Amadeus", "2025-06-01", 120, day.AddDate(0, 0, -40)),
+		record("google", "2025-06-01", 110, day.AddDate(0, 0, -1)),
+		record("Amadeus", "2025-06-01", 100, day),
+		record("Amadeus", "2025-06-02", 90, day),
+	}))
+
+	query := entity.PriceHistoryQuery{Origin: " madrid", Destination: "LISBON", DateDeparture: "2025-06-01"}
+	records, err := repo.Prices(ctx, query, day.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, 110.0, records[0].Price)
+	assert.Equal(t, "madrid", records[1].Origin)
+	assert.True(t, day.Equal(records[1].ObservedAt))
+
+	query.Provider = "Amadeus"
+	query.DateDeparture = ""
+	records, err = repo.Prices(ctx, query, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+}
+
+func TestSummarize(t *testing.T) {
+	day := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []entity.PriceRecord{
+		record("Amadeus", "2025-06-01", 200, day),
+		record("google", "2025-06-01", 180, day.Add(time.Hour)),
+		record("Amadeus", "2025-06-01", 150, day.AddDate(0, 0, 1)),
+		record("google", "2025-06-01", 130, day.AddDate(0, 0, 1)),
+	}
+
+	history := Summarize(entity.PriceHistoryQuery{Origin: "Madrid", Destination: "Lisbon"}, records)
+
+	assert.Equal(t, []entity.PriceTrendPoint{
+		{Date: "2025-05-01", MinPrice: 180, AvgPrice: 190, MaxPrice: 200, Observations: 2},
+		{Date: "2025-05-02", MinPrice: 130, AvgPrice: 140, MaxPrice: 150, Observations: 2},
+	}, history.Trend)
+	assert.Equal(t, 130.0, history.MinPrice)
+	assert.Equal(t, 165.0, history.AvgPrice)
+	assert.Equal(t, 200.0, history.MaxPrice)
+	assert.Equal(t, 130.0, history.CurrentPrice)
+	assert.Equal(t, AdviceLow, history.Advice)
+
+	empty := Summarize(entity.PriceHistoryQuery{Origin: "Madrid", Destination: "Lisbon"}, nil)
+	assert.Empty(t, empty.Trend)
+	assert.Empty(t, empty.Advice)
+}
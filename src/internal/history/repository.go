@@ -0,0 +1,21 @@
+package history
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+// Repository stores the prices observed in the searches
+type Repository interface {
+	AddPrices(ctx context.Context, records []entity.PriceRecord) error
+	// Prices returns the records of the query observed after since, oldest first
+	Prices(ctx context.Context, query entity.PriceHistoryQuery, since time.Time) ([]entity.PriceRecord, error)
+}
+
+// NormalizeRoute makes the searches of the same route match, "Madrid " and "madrid"
+func NormalizeRoute(origin, destination string) (string, string) {
+	return strings.ToLower(strings.TrimSpace(origin)), strings.ToLower(strings.TrimSpace(destination))
+}
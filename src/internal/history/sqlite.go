@@ -0,0 +1,119 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS price_records (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	origin         TEXT NOT NULL,
+	destination    TEXT NOT NULL,
+	date_departure TEXT NOT NULL,
+	provider       TEXT NOT NULL,
+	price          REAL NOT NULL,
+	observed_at    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_price_records_route
+	ON price_records (origin, destination, observed_at);
+`
+
+// SQLiteRepository keeps the price history in a SQLite database file
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository opens the database and creates the tables that don't exist,
+// ":memory:" can be used for a temporary database
+func NewSQLiteRepository(path string) (*SQLiteRepository, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening the price history database: %w", err)
+	}
+	// sqlite allows a single writer, and every connection of ":memory:" is a different database
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating the price history tables: %w", err)
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
+// DB returns the database, the saved searches of the alerts are stored in it too
+func (r *SQLiteRepository) DB() *sql.DB {
+	return r.db
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *SQLiteRepository) AddPrices(ctx context.Context, records []entity.PriceRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO price_records
+		(origin, destination, date_departure, provider, price, observed_at) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rec := range records {
+		origin, destination := NormalizeRoute(rec.Origin, rec.Destination)
+		_, err := stmt.ExecContext(ctx, origin, destination, rec.DateDeparture, rec.Provider, rec.Price, rec.ObservedAt.UnixMilli())
+		if err != nil {
+			return fmt.Errorf("error inserting price record: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) Prices(ctx context.Context, query entity.PriceHistoryQuery, since time.Time) ([]entity.PriceRecord, error) {
+	origin, destination := NormalizeRoute(query.Origin, query.Destination)
+
+	conditions := []string{"origin = ?", "destination = ?", "observed_at >= ?"}
+	args := []any{origin, destination, since.UnixMilli()}
+	if query.DateDeparture != "" {
+		conditions = append(conditions, "date_departure = ?")
+		args = append(args, query.DateDeparture)
+	}
+	if query.Provider != "" {
+		conditions = append(conditions, "provider = ?")
+		args = append(args, query.Provider)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT origin, destination, date_departure, provider, price, observed_at
+		FROM price_records WHERE `+strings.Join(conditions, " AND ")+` ORDER BY observed_at, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying the price history: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]entity.PriceRecord, 0)
+	for rows.Next() {
+		var rec entity.PriceRecord
+		var observedAt int64
+		if err := rows.Scan(&rec.Origin, &rec.Destination, &rec.DateDeparture, &rec.Provider, &rec.Price, &observedAt); err != nil {
+			return nil, err
+		}
+		rec.ObservedAt = time.UnixMilli(observedAt).UTC()
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
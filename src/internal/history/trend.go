@@ -0,0 +1,81 @@
+package history
+
+import (
+	"math"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const (
+	AdviceLow     = "low"
+	AdviceTypical = "typical"
+	AdviceHigh    = "high"
+
+	// adviceMargin is how far from the average the current price has to be to
+	// be considered low or high
+	adviceMargin = 0.1
+
+	trendDateLayout = "2006-01-02"
+)
+
+// Summarize builds the daily trend and the statistics of the records, which
+// must be sorted from the oldest. The current price is the cheapest of the last
+// day with observations
+func Summarize(query entity.PriceHistoryQuery, records []entity.PriceRecord) entity.PriceHistory {
+	origin, destination := NormalizeRoute(query.Origin, query.Destination)
+	history := entity.PriceHistory{
+		Origin:        origin,
+		Destination:   destination,
+		DateDeparture: query.DateDeparture,
+		Records:       records,
+		Trend:         make([]entity.PriceTrendPoint, 0),
+	}
+	if len(records) == 0 {
+		return history
+	}
+
+	total := 0.0
+	history.MinPrice = math.MaxFloat64
+	for _, rec := range records {
+		total += rec.Price
+		history.MinPrice = math.Min(history.MinPrice, rec.Price)
+		history.MaxPrice = math.Max(history.MaxPrice, rec.Price)
+
+		day := rec.ObservedAt.UTC().Format(trendDateLayout)
+		last := len(history.Trend) - 1
+		if last < 0 || history.Trend[last].Date != day {
+			history.Trend = append(history.Trend, entity.PriceTrendPoint{Date: day, MinPrice: rec.Price, MaxPrice: rec.Price})
+			last++
+		}
+
+		point := &history.Trend[last]
+		point.MinPrice = math.Min(point.MinPrice, rec.Price)
+		point.MaxPrice = math.Max(point.MaxPrice, rec.Price)
+		// AvgPrice keeps the sum until all the records of the day are added
+		point.AvgPrice += rec.Price
+		point.Observations++
+	}
+
+	for i := range history.Trend {
+		history.Trend[i].AvgPrice = round(history.Trend[i].AvgPrice / float64(history.Trend[i].Observations))
+	}
+	history.AvgPrice = round(total / float64(len(records)))
+	history.CurrentPrice = history.Trend[len(history.Trend)-1].MinPrice
+	history.Advice = advice(history.CurrentPrice, history.AvgPrice)
+	return history
+}
+
+func advice(current, avg float64) string {
+	switch {
+	case current <= avg*(1-adviceMargin):
+		return AdviceLow
+	case current >= avg*(1+adviceMargin):
+		return AdviceHigh
+	default:
+		return AdviceTypical
+	}
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,291 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	services "github.com/mariajdab/flight-price/internal/flights/service"
+)
+
+var (
+	ErrNotFound  = errors.New("search job not found")
+	ErrQueueFull = errors.New("too many search jobs waiting, try again later")
+	ErrFinished  = errors.New("the search job already finished")
+)
+
+const (
+	DefaultWorkers   = 4
+	DefaultTTL       = 30 * time.Minute
+	DefaultQueueSize = 100
+
+	cleanupInterval = time.Minute
+)
+
+// Searcher runs the searches of the jobs, it is implemented by services.FlightService
+type Searcher interface {
+	SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) entity.FlightPriceResponse
+	SearchFlexibleDatesProgress(ctx context.Context, param entity.FlexibleSearchParam, onProgress func(done, total int)) (entity.FlexibleSearchResponse, error)
+}
+
+type job struct {
+	state  entity.SearchJob
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Manager runs the search jobs in a fixed number of workers, the jobs wait in
+// a queue until a worker is free. A job is removed when it expires, the expiry
+// starts again when it finishes so the results can be polled for a while
+type Manager struct {
+	mu       sync.Mutex
+	jobs     map[string]*job
+	queue    chan *job
+	searcher Searcher
+	workers  int
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func NewManager(searcher Searcher, workers int, ttl time.Duration) *Manager {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Manager{
+		jobs:     make(map[string]*job),
+		queue:    make(chan *job, DefaultQueueSize),
+		searcher: searcher,
+		workers:  workers,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// Start runs the workers until the context is done, then the running jobs are cancelled
+func (m *Manager) Start(ctx context.Context) {
+	for i := 0; i < m.workers; i++ {
+		go m.work(ctx)
+	}
+
+	go func() {
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				m.cancelAll()
+				return
+			case <-ticker.C:
+				m.removeExpired()
+			}
+		}
+	}()
+}
+
+// Submit queues the job, it fails when the queue is full
+func (m *Manager) Submit(req entity.SearchJobRequest) (entity.SearchJob, error) {
+	id, err := newID()
+	if err != nil {
+		return entity.SearchJob{}, err
+	}
+
+	total := len(req.Searches)
+	if req.Flexible != nil {
+		total = 0 // known when the dates of the range are calculated
+	}
+
+	now := m.now()
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		state: entity.SearchJob{
+			ID:        id,
+			Status:    entity.JobPending,
+			Request:   req,
+			Total:     total,
+			CreatedAt: now,
+			ExpiresAt: now.Add(m.ttl),
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case m.queue <- j:
+	default:
+		cancel()
+		return entity.SearchJob{}, ErrQueueFull
+	}
+	m.jobs[id] = j
+	return j.state, nil
+}
+
+// Get returns the current state of the job
+func (m *Manager) Get(id string) (entity.SearchJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, exists := m.jobs[id]
+	if !exists {
+		return entity.SearchJob{}, ErrNotFound
+	}
+	return snapshot(j.state), nil
+}
+
+// Cancel stops a pending or running job, the results found until then are kept
+func (m *Manager) Cancel(id string) (entity.SearchJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, exists := m.jobs[id]
+	if !exists {
+		return entity.SearchJob{}, ErrNotFound
+	}
+	if j.state.FinishedAt != nil {
+		return snapshot(j.state), ErrFinished
+	}
+
+	j.cancel()
+	m.finish(j, entity.JobCancelled, "")
+	return snapshot(j.state), nil
+}
+
+func (m *Manager) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-m.queue:
+			m.run(j)
+		}
+	}
+}
+
+func (m *Manager) run(j *job) {
+	m.mu.Lock()
+	if j.state.Status != entity.JobPending {
+		// cancelled while it was waiting
+		m.mu.Unlock()
+		return
+	}
+	started := m.now()
+	j.state.Status = entity.JobRunning
+	j.state.StartedAt = &started
+	req := j.state.Request
+	m.mu.Unlock()
+
+	defer j.cancel()
+
+	if req.Flexible != nil {
+		m.runFlexible(j, *req.Flexible)
+		return
+	}
+	m.runSearches(j, req)
+}
+
+func (m *Manager) runSearches(j *job, req entity.SearchJobRequest) {
+	for _, search := range req.Searches {
+		if j.ctx.Err() != nil {
+			return
+		}
+
+		resp := m.searcher.SearchFlights(j.ctx, search)
+		resp, err := services.ApplyQuery(resp, req.Filters)
+
+		m.mu.Lock()
+		if j.state.FinishedAt != nil {
+			m.mu.Unlock()
+			return
+		}
+		if err != nil {
+			m.finish(j, entity.JobFailed, err.Error())
+			m.mu.Unlock()
+			return
+		}
+		j.state.Results = append(j.state.Results, resp)
+		j.state.Done++
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finish(j, entity.JobCompleted, "")
+}
+
+func (m *Manager) runFlexible(j *job, param entity.FlexibleSearchParam) {
+	resp, err := m.searcher.SearchFlexibleDatesProgress(j.ctx, param, func(done, total int) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		j.state.Done = done
+		j.state.Total = total
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.finish(j, entity.JobFailed, err.Error())
+		return
+	}
+	j.state.Flexible = &resp
+	m.finish(j, entity.JobCompleted, "")
+}
+
+// finish sets the final status once, a cancelled job is not completed later. Must hold mu
+func (m *Manager) finish(j *job, status, errMsg string) {
+	if j.state.FinishedAt != nil {
+		return
+	}
+	finished := m.now()
+	j.state.Status = status
+	j.state.Error = errMsg
+	j.state.FinishedAt = &finished
+	j.state.ExpiresAt = finished.Add(m.ttl)
+}
+
+func (m *Manager) removeExpired() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for id, j := range m.jobs {
+		if now.After(j.state.ExpiresAt) {
+			j.cancel()
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (m *Manager) cancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, j := range m.jobs {
+		j.cancel()
+		m.finish(j, entity.JobCancelled, "")
+	}
+	log.Println("search jobs stopped")
+}
+
+// snapshot copies the job so the caller can read it while the worker updates it
+func snapshot(state entity.SearchJob) entity.SearchJob {
+	state.Results = append([]entity.FlightPriceResponse(nil), state.Results...)
+	return state
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
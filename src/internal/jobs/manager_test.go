@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSearcher answers each search after a value is sent to release
+type blockingSearcher struct {
+	release chan struct{}
+}
+
+func (s *blockingSearcher) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) entity.FlightPriceResponse {
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return entity.FlightPriceResponse{}
+	}
+	flight := entity.Flight{ProviderName: "stub", Price: 100}
+	return entity.FlightPriceResponse{
+		OriginName: criteria.Origin,
+		Flights:    []entity.Flight{flight},
+		Cheapest:   flight,
+	}
+}
+
+func (s *blockingSearcher) SearchFlexibleDatesProgress(ctx context.Context, _ entity.FlexibleSearchParam, onProgress func(done, total int)) (entity.FlexibleSearchResponse, error) {
+	for i := 1; i <= 2; i++ {
+		select {
+		case <-s.release:
+			onProgress(i, 2)
+		case <-ctx.Done():
+			return entity.FlexibleSearchResponse{}, ctx.Err()
+		}
+	}
+	return entity.FlexibleSearchResponse{BestDate: "2030-05-10", BestPrice: 90}, nil
+}
+
+func searchesRequest() entity.SearchJobRequest {
+	return entity.SearchJobRequest{
+		Searches: []entity.FlightSearchParam{
+			{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2030-05-10"},
+			{Origin: "Paris", Destination: "Lisbon", DateDeparture: "2030-05-10"},
+		},
+		Filters: entity.DefaultFlightQuery(),
+	}
+}
+
+func waitStatus(t *testing.T, m *Manager, id, status string) entity.SearchJob {
+	var job entity.SearchJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(id)
+		require.NoError(t, err)
+		return job.Status == status
+	}, time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestManager_RunsSearchesWithProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	searcher := &blockingSearcher{release: make(chan struct{})}
+	m := NewManager(searcher, 1, time.Minute)
+	m.Start(ctx)
+
+	job, err := m.Submit(searchesRequest())
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobPending, job.Status)
+	assert.Equal(t, 2, job.Total)
+
+	searcher.release <- struct{}{}
+	require.Eventually(t, func() bool {
+		job, _ = m.Get(job.ID)
+		return job.Done == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, entity.JobRunning, job.Status)
+	require.Len(t, job.Results, 1)
+	assert.Equal(t, "Madrid", job.Results[0].OriginName)
+
+	searcher.release <- struct{}{}
+	job = waitStatus(t, m, job.ID, entity.JobCompleted)
+	assert.Len(t, job.Results, 2)
+	assert.NotNil(t, job.FinishedAt)
+}
+
+func TestManager_FlexibleJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	searcher := &blockingSearcher{release: make(chan struct{}, 2)}
+	searcher.release <- struct{}{}
+	searcher.release <- struct{}{}
+
+	m := NewManager(searcher, 1, time.Minute)
+	m.Start(ctx)
+
+	job, err := m.Submit(entity.SearchJobRequest{Flexible: &entity.FlexibleSearchParam{Origin: "Madrid", Destination: "Lisbon"}})
+	require.NoError(t, err)
+
+	job = waitStatus(t, m, job.ID, entity.JobCompleted)
+	assert.Equal(t, 2, job.Done)
+	assert.Equal(t, 2, job.Total)
+	require.NotNil(t, job.Flexible)
+	assert.Equal(t, "2030-05-10", job.Flexible.BestDate)
+}
+
+func TestManager_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := NewManager(&blockingSearcher{release: make(chan struct{})}, 1, time.Minute)
+	m.Start(ctx)
+
+	running, err := m.Submit(searchesRequest())
+	require.NoError(t, err)
+	waitStatus(t, m, running.ID, entity.JobRunning)
+
+	// the only worker is busy, the second job waits in the queue
+	pending, err := m.Submit(searchesRequest())
+	require.NoError(t, err)
+
+	for _, id := range []string{running.ID, pending.ID} {
+		job, err := m.Cancel(id)
+		require.NoError(t, err)
+		assert.Equal(t, entity.JobCancelled, job.Status)
+	}
+
+	_, err = m.Cancel(running.ID)
+	assert.ErrorIs(t, err, ErrFinished)
+	_, err = m.Cancel("unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManager_RemovesExpiredJobs(t *testing.T) {
+	m := NewManager(&blockingSearcher{}, 1, time.Minute)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	job, err := m.Submit(searchesRequest())
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	m.removeExpired()
+
+	_, err = m.Get(job.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
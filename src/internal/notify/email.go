@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// DefaultSMTPTimeout limits the whole delivery of an email, a server that
+// stops answering would block the alerts scheduler
+const DefaultSMTPTimeout = 30 * time.Second
+
+// headerReplacer avoids injecting headers with the subject
+var headerReplacer = strings.NewReplacer("\r", "", "\n", " ")
+
+// SMTP sends the message by email to the recipient of the message, the
+// configured recipients get the messages for the operators
+type SMTP struct {
+	addr     string
+	from     string
+	to       []string
+	username string
+	password string
+	timeout  time.Duration
+}
+
+func NewSMTP(addr, from string, to []string, username, password string) *SMTP {
+	return &SMTP{addr: addr, from: from, to: to, username: username, password: password, timeout: DefaultSMTPTimeout}
+}
+
+func (s *SMTP) Name() string {
+	return "email"
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	recipients := s.to
+	if msg.Recipient != "" {
+		recipients = []string{msg.Recipient}
+	}
+	if len(recipients) == 0 {
+		return errors.New("no email recipients")
+	}
+
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to the smtp server: %w", err)
+	}
+	// the smtp client doesn't use the context, the deadline of the connection stops it
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("error setting the smtp deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error connecting to the smtp server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("error starting tls: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, host)); err != nil {
+			return fmt.Errorf("error authenticating: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return fmt.Errorf("error in MAIL command: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("error in RCPT command for %s: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("error in DATA command: %w", err)
+	}
+	if _, err := w.Write(s.body(msg, recipients)); err != nil {
+		return fmt.Errorf("error writing the email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error sending the email: %w", err)
+	}
+	return client.Quit()
+}
+
+func (s *SMTP) body(msg Message, recipients []string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerReplacer.Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
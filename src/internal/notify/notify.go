@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	EventPriceDrop         = "price_drop"
+	EventProviderOutage    = "provider_outage"
+	EventProviderRecovered = "provider_recovered"
+)
+
+// Message is the notification sent to every channel, the channels decide how
+// to render it: email body, signed JSON payload or chat text
+type Message struct {
+	Event     string    `json:"event"`
+	Subject   string    `json:"subject"`
+	Text      string    `json:"text"`
+	Recipient string    `json:"recipient,omitempty"` // email of the user the message is for, empty for the operators
+	Data      any       `json:"data,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Notifier is a channel that delivers messages
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Multi sends the message to all the channels, a failing channel does not
+// stop the others and the errors are returned together
+type Multi []Notifier
+
+func (m Multi) Name() string {
+	return "multi"
+}
+
+func (m Multi) Send(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Send(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Log writes the messages in the log, used when no channel is configured
+type Log struct{}
+
+func (Log) Name() string {
+	return "log"
+}
+
+func (Log) Send(_ context.Context, msg Message) error {
+	log.Printf("notification %s: %s - %s", msg.Event, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage() Message {
+	return Message{
+		Event:     EventPriceDrop,
+		Subject:   "Price drop: Madrid - Lisbon $120.00",
+		Text:      "Madrid - Lisbon on 2025-06-01 is now $120.00",
+		Recipient: "maria@example.com",
+		CreatedAt: time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhook_SignsThePayload(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		expected := "sha256=" + Sign("s3cret", r.Header.Get(TimestampHeader), body)
+		assert.Equal(t, expected, r.Header.Get(SignatureHeader))
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL, "s3cret", server.Client()).Send(context.Background(), testMessage())
+	require.NoError(t, err)
+	assert.Equal(t, testMessage().Subject, received.Subject)
+	assert.Equal(t, EventPriceDrop, received.Event)
+}
+
+func TestSlack_PostsText(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewSlack(server.URL, server.Client()).Send(context.Background(), testMessage()))
+	assert.Equal(t, "*Price drop: Madrid - Lisbon $120.00*\nMadrid - Lisbon on 2025-06-01 is now $120.00", payload["text"])
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL, "", server.Client()).Send(context.Background(), testMessage())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "500")
+}
+
+// fakeSMTPServer accepts one email and sends the commands and the data it received
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var transcript strings.Builder
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ready")
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				received <- transcript.String()
+				return
+			}
+			transcript.WriteString(line)
+
+			switch {
+			case inData && line == ".\r\n":
+				inData = false
+				reply("250 queued")
+			case inData:
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				reply("354 go ahead")
+			case strings.HasPrefix(line, "QUIT"):
+				reply("221 bye")
+				received <- transcript.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSMTP_SendsEmail(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+
+	notifier := NewSMTP(addr, "alerts@flight-price.dev", []string{"ops@example.com"}, "", "")
+	require.NoError(t, notifier.Send(context.Background(), testMessage()))
+
+	transcript := <-received
+	assert.Contains(t, transcript, "MAIL FROM:<alerts@flight-price.dev>")
+	// the alert of the user doesn't go to the operators
+	assert.Contains(t, transcript, "RCPT TO:<maria@example.com>")
+	assert.NotContains(t, transcript, "ops@example.com")
+	assert.Contains(t, transcript, "Subject: Price drop: Madrid - Lisbon $120.00")
+	assert.Contains(t, transcript, "is now $120.00")
+
+	addr, received = fakeSMTPServer(t)
+	notifier = NewSMTP(addr, "alerts@flight-price.dev", []string{"ops@example.com"}, "", "")
+	msg := testMessage()
+	msg.Recipient = ""
+	require.NoError(t, notifier.Send(context.Background(), msg))
+	assert.Contains(t, <-received, "RCPT TO:<ops@example.com>")
+}
+
+func TestSMTP_TimesOut(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		// accepts the connection and never greets
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			_, _ = io.Copy(io.Discard, conn)
+		}
+	}()
+
+	notifier := NewSMTP(listener.Addr().String(), "alerts@flight-price.dev", []string{"ops@example.com"}, "", "")
+	notifier.timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err = notifier.Send(context.Background(), testMessage())
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+type failingNotifier struct {
+	failures int
+	calls    int
+}
+
+func (n *failingNotifier) Name() string { return "failing" }
+
+func (n *failingNotifier) Send(context.Context, Message) error {
+	n.calls++
+	if n.calls <= n.failures {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestRetrying(t *testing.T) {
+	t.Run("delivered after retries", func(t *testing.T) {
+		var deadLetters bytes.Buffer
+		channel := &failingNotifier{failures: 2}
+
+		err := WithRetry(channel, 3, time.Millisecond, NewDeadLetterLog(&deadLetters)).Send(context.Background(), testMessage())
+		require.NoError(t, err)
+		assert.Equal(t, 3, channel.calls)
+		assert.Empty(t, deadLetters.String())
+	})
+
+	t.Run("dead letter when all attempts fail", func(t *testing.T) {
+		var deadLetters bytes.Buffer
+		channel := &failingNotifier{failures: 5}
+
+		err := WithRetry(channel, 2, time.Millisecond, NewDeadLetterLog(&deadLetters)).Send(context.Background(), testMessage())
+		require.Error(t, err)
+		assert.Equal(t, 2, channel.calls)
+
+		var letter deadLetter
+		require.NoError(t, json.Unmarshal(deadLetters.Bytes(), &letter))
+		assert.Equal(t, "failing", letter.Channel)
+		assert.Contains(t, letter.Error, "unavailable")
+		assert.Equal(t, testMessage().Subject, letter.Message.Subject)
+	})
+}
+
+func TestMulti_SendsToEveryChannel(t *testing.T) {
+	broken := &failingNotifier{failures: 1}
+	working := &failingNotifier{}
+
+	err := Multi{broken, working}.Send(context.Background(), testMessage())
+	require.Error(t, err)
+	assert.Equal(t, 1, working.calls)
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAttempts = 3
+	DefaultBackoff  = 2 * time.Second
+)
+
+// Retrying sends the message again when the channel fails, waiting twice the
+// backoff after each attempt. The messages that could not be delivered are
+// written in the dead letter log
+type Retrying struct {
+	notifier   Notifier
+	attempts   int
+	backoff    time.Duration
+	deadLetter *DeadLetterLog
+}
+
+func WithRetry(notifier Notifier, attempts int, backoff time.Duration, deadLetter *DeadLetterLog) *Retrying {
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+	return &Retrying{
+		notifier:   notifier,
+		attempts:   attempts,
+		backoff:    backoff,
+		deadLetter: deadLetter,
+	}
+}
+
+func (r *Retrying) Name() string {
+	return r.notifier.Name()
+}
+
+func (r *Retrying) Send(ctx context.Context, msg Message) error {
+	var err error
+	wait := r.backoff
+
+	for attempt := 1; attempt <= r.attempts; attempt++ {
+		if err = r.notifier.Send(ctx, msg); err == nil {
+			return nil
+		}
+		log.Printf("warning: %s notification failed (attempt %d of %d): %v", r.notifier.Name(), attempt, r.attempts, err)
+
+		if attempt == r.attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return r.fail(msg, ctx.Err())
+		case <-time.After(wait):
+			wait *= 2
+		}
+	}
+	return r.fail(msg, err)
+}
+
+func (r *Retrying) fail(msg Message, err error) error {
+	err = fmt.Errorf("%s notification not delivered: %w", r.notifier.Name(), err)
+	if r.deadLetter != nil {
+		r.deadLetter.Write(r.notifier.Name(), msg, err)
+	}
+	return err
+}
+
+// DeadLetterLog keeps the messages that could not be delivered, one JSON per line
+type DeadLetterLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewDeadLetterLog(w io.Writer) *DeadLetterLog {
+	return &DeadLetterLog{w: w}
+}
+
+type deadLetter struct {
+	FailedAt time.Time `json:"failed_at"`
+	Channel  string    `json:"channel"`
+	Error    string    `json:"error"`
+	Message  Message   `json:"message"`
+}
+
+func (d *DeadLetterLog) Write(channel string, msg Message, sendErr error) {
+	line, err := json.Marshal(deadLetter{
+		FailedAt: time.Now(),
+		Channel:  channel,
+		Error:    sendErr.Error(),
+		Message:  msg,
+	})
+	if err != nil {
+		log.Printf("error encoding dead letter: %v", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.w.Write(append(line, '\n')); err != nil {
+		log.Printf("error writing dead letter: %v", err)
+	}
+}
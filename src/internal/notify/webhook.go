@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Flight-Price-Signature"
+	TimestampHeader = "X-Flight-Price-Timestamp"
+)
+
+// Webhook posts the message as JSON. When a secret is set the payload is signed
+// with HMAC-SHA256 so the receiver can check it comes from us
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhook(url, secret string, client *http.Client) *Webhook {
+	return &Webhook{url: url, secret: secret, client: client}
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("error encoding the message: %w", err)
+	}
+
+	headers := make(map[string]string)
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[TimestampHeader] = timestamp
+		headers[SignatureHeader] = "sha256=" + Sign(w.secret, timestamp, body)
+	}
+	return postJSON(ctx, w.client, w.url, body, headers)
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body", the timestamp is part
+// of the signature to reject replayed requests
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Slack posts the message to a Slack compatible incoming webhook
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlack(url string, client *http.Client) *Slack {
+	return &Slack{url: url, client: client}
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", msg.Subject, msg.Text),
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding the message: %w", err)
+	}
+	return postJSON(ctx, s.client, s.url, body, nil)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending the request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
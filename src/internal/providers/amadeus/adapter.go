@@ -16,6 +16,10 @@ func NewAdapterAmadeus(client *Client) *Amadeus {
 	return &Amadeus{client: client}
 }
 
+func (p *Amadeus) Name() string {
+	return providerName
+}
+
 func (p *Amadeus) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
 	origin := helper.CityToIATACode(criteria.Origin)
 	destination := helper.CityToIATACode(criteria.Destination)
@@ -34,3 +38,7 @@ func (p *Amadeus) SearchFlights(ctx context.Context, criteria entity.FlightSearc
 	}
 	return flights, nil
 }
+
+func (p *Amadeus) PriceOffer(ctx context.Context, offer entity.StoredOffer) (entity.PricedOffer, error) {
+	return p.client.PriceOffer(ctx, offer)
+}
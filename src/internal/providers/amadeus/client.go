@@ -16,10 +16,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mariajdab/flight-price/helper"
 	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
 )
 
-const providerName = "Amadeus"
+const (
+	providerName = "Amadeus"
+	timeLayout   = "2006-01-02T15:04:05"
+)
 
 type Client struct {
 	httpClient http.Client
@@ -58,6 +63,26 @@ func (c *Client) GetFlights(ctx context.Context, params entity.FlightSearchParam
 	return resp, nil
 }
 
+// PriceOffer confirms the price of an offer of a previous search, amadeus needs
+// the whole offer and not only its id, which is just its position in the search
+func (c *Client) PriceOffer(ctx context.Context, offer entity.StoredOffer) (entity.PricedOffer, error) {
+	if offer.Raw == nil {
+		return entity.PricedOffer{}, providers.ErrOfferNotFound
+	}
+
+	token, err := c.getAccessToken(ctx)
+	if err != nil {
+		return entity.PricedOffer{}, err
+	}
+
+	priced, err := c.priceFlightOffer(ctx, token, offer.Raw)
+	if err != nil {
+		return entity.PricedOffer{}, fmt.Errorf("error in priceFlightOffer: %w", err)
+	}
+
+	return pricedOffer(offer.Ref.Token, offer.Price, priced)
+}
+
 func (c *Client) getAccessToken(ctx context.Context) (string, error) {
 	const tokenEndpoint = "v1/security/oauth2/token"
 
@@ -71,7 +96,7 @@ func (c *Client) getAccessToken(ctx context.Context) (string, error) {
 	data.Add("client_id", c.apikey)
 	data.Add("client_secret", c.secret)
 
-	req, err := http.NewRequest("POST", tokenURL.String(), bytes.NewBufferString(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL.String(), bytes.NewBufferString(data.Encode()))
 	if err != nil {
 		log.Println(fmt.Errorf("error creando request: %v", err))
 		return "", err
@@ -79,8 +104,7 @@ func (c *Client) getAccessToken(ctx context.Context) (string, error) {
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		log.Println(fmt.Errorf("error creando request: %v", err))
 		return "", err
@@ -132,8 +156,7 @@ func (c *Client) getFlightOffers(ctx context.Context, token string, params entit
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +177,92 @@ func (c *Client) getFlightOffers(ctx context.Context, token string, params entit
 	return flights.Data, nil
 }
 
+// priceFlightOffer confirms the price of the offer, the response has the offer with the current price
+func (c *Client) priceFlightOffer(ctx context.Context, token string, rawOffer json.RawMessage) (entity.FlightOffer, error) {
+	const flightOffersPricingEndpoint = "v1/shopping/flight-offers/pricing"
+
+	pricingURL, err := url.Parse(fmt.Sprintf("%s/%s", c.baseURL, flightOffersPricingEndpoint))
+	if err != nil {
+		return entity.FlightOffer{}, err
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"data": map[string]any{
+			"type":         "flight-offers-pricing",
+			"flightOffers": []json.RawMessage{rawOffer},
+		},
+	})
+	if err != nil {
+		return entity.FlightOffer{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pricingURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return entity.FlightOffer{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// amadeus documents the pricing as a GET with body
+	req.Header.Set("X-HTTP-Method-Override", http.MethodGet)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return entity.FlightOffer{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return entity.FlightOffer{}, fmt.Errorf("failed to price flight offer (status %d): %s", resp.StatusCode, string(errorBody))
+	}
+
+	var pricing entity.FlightOffersPricingAmadeusResp
+	if err := json.NewDecoder(resp.Body).Decode(&pricing); err != nil {
+		log.Println("internal error during decode pricing from amadeus provider", err)
+		return entity.FlightOffer{}, err
+	}
+	if len(pricing.Data.FlightOffers) == 0 {
+		return entity.FlightOffer{}, errors.New("the pricing does not have the flight offer")
+	}
+
+	return pricing.Data.FlightOffers[0], nil
+}
+
+// pricedOffer compares the confirmed price with the price of the search
+func pricedOffer(token string, previousPrice float64, offer entity.FlightOffer) (entity.PricedOffer, error) {
+	price, err := strconv.ParseFloat(offer.Price.Total, 64)
+	if err != nil {
+		return entity.PricedOffer{}, fmt.Errorf("error parsing confirmed price: %w", err)
+	}
+
+	currency := offer.Price.Currency
+	if currency == "" {
+		currency = entity.DefaultCurrency
+	}
+
+	rules := make([]entity.FareRule, 0, len(offer.FareRules.Rules))
+	for _, r := range offer.FareRules.Rules {
+		rule := entity.FareRule{Category: r.Category, Allowed: !r.NotApplicable}
+		if r.MaxPenaltyAmount != "" {
+			if rule.MaxPenalty, err = strconv.ParseFloat(r.MaxPenaltyAmount, 64); err != nil {
+				log.Printf("warning: invalid penalty %q for the rule %s", r.MaxPenaltyAmount, r.Category)
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return entity.PricedOffer{
+		Provider:          providerName,
+		Token:             token,
+		Currency:          currency,
+		PreviousPrice:     previousPrice,
+		Price:             price,
+		PriceChange:       math.Round((price-previousPrice)*100) / 100,
+		LastTicketingDate: offer.LastTicketingDate,
+		FareRules:         rules,
+	}, nil
+}
+
 // offersPreProcessResponse aim to preprocess the data and obtain the cheapest and fast flight for the provider
 func offersPreProcessResponse(offers []entity.FlightOffer) (entity.FlightSearchResponse, error) {
 	if len(offers) == 0 {
@@ -164,12 +273,7 @@ func offersPreProcessResponse(offers []entity.FlightOffer) (entity.FlightSearchR
 	cheapest := offers[0]
 	fastest := offers[0]
 	lastPriceCheapest := math.MaxFloat32
-
-	fastestDuration, err := parseDuration(fastest.Itineraries[0].Duration)
-	if err != nil {
-		fmt.Println("Error parsing fastest duration:", err)
-		return entity.FlightSearchResponse{}, err
-	}
+	fastestDuration := time.Duration(math.MaxInt64)
 
 	resp := entity.FlightSearchResponse{
 		Flights: make([]entity.Flight, 0, len(offers)),
@@ -181,19 +285,27 @@ func offersPreProcessResponse(offers []entity.FlightOffer) (entity.FlightSearchR
 			return entity.FlightSearchResponse{}, err
 		}
 
+		if len(offer.Itineraries) == 0 {
+			log.Println("the flight offer does not have itineraries: ", offer)
+			continue
+		}
+
+		segments, err := itinerariesSegments(offer.Itineraries)
+		if err != nil {
+			log.Printf("warning: skipping offer %v with invalid segments: %v", offer.ID, err)
+			continue
+		}
+		if len(segments) == 0 {
+			log.Printf("warning: skipping offer %v without segments", offer.ID)
+			continue
+		}
+
 		// check cheapest flight
 		if price < lastPriceCheapest {
 			cheapest = offer
 			lastPriceCheapest = price
 		}
 
-		if len(offer.Itineraries) == 0 {
-			log.Println("the flight offer does not have itineraries: ", offer)
-			continue
-		}
-
-		segments := make([]entity.Segment, 0)
-		// iterate over itineraries just in case it has more than one item
 		for _, it := range offer.Itineraries {
 			duration, err := parseDuration(it.Duration)
 			if err != nil {
@@ -204,24 +316,28 @@ func offersPreProcessResponse(offers []entity.FlightOffer) (entity.FlightSearchR
 				fastest = offer
 				fastestDuration = duration
 			}
-			for _, s := range it.Segments {
-				segments = append(segments, entity.Segment{
-					DepartureAirport:   s.Departure.IataCode,
-					DepartureTime:      s.Departure.At,
-					DestinationAirport: s.Arrival.IataCode,
-					ArrivalTime:        s.Arrival.At,
-				})
-			}
 		}
 
 		// save flight data in a useful struct
+		checkedBags, cabinBags, fareBrand := fareDetails(offer)
 		resp.Flights = append(resp.Flights, entity.Flight{
+			Ref:             &entity.ProviderRef{ID: offer.ID},
+			Raw:             offer.Raw,
 			Price:           price,
 			DurationMinutes: durationToMinutes(offer.Itineraries[0].Duration),
+			Stops:           countStops(offer.Itineraries[0].Segments),
+			LayoverMinutes:  helper.LayoverMinutes(segments),
 			Segments:        segments,
+			CheckedBags:     checkedBags,
+			CabinBags:       cabinBags,
+			FareBrand:       fareBrand,
 		})
 	}
 
+	if len(resp.Flights) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("no valid offers in the list")
+	}
+
 	priceCh, err := strconv.ParseFloat(cheapest.Price.Total, 64)
 	if err != nil {
 		return entity.FlightSearchResponse{}, fmt.Errorf("error parsing cheapest price: %w", err)
@@ -233,8 +349,12 @@ func offersPreProcessResponse(offers []entity.FlightOffer) (entity.FlightSearchR
 
 	resp.Provider = providerName
 	resp.Currency = entity.DefaultCurrency
-	resp.Cheapest = createFlightFromOffer(cheapest, priceCh)
-	resp.Fastest = createFlightFromOffer(fastest, priceFt)
+	if resp.Cheapest, err = createFlightFromOffer(cheapest, priceCh); err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("error creating cheapest flight: %w", err)
+	}
+	if resp.Fastest, err = createFlightFromOffer(fastest, priceFt); err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("error creating fastest flight: %w", err)
+	}
 
 	return resp, nil
 }
@@ -247,25 +367,110 @@ func parseDuration(durationStr string) (time.Duration, error) {
 }
 
 // createFlightFromOffer is a helper function to create Flight from Offer
-func createFlightFromOffer(offer entity.FlightOffer, price float64) entity.Flight {
-	segments := make([]entity.Segment, 0, len(offer.Itineraries[0].Segments))
+func createFlightFromOffer(offer entity.FlightOffer, price float64) (entity.Flight, error) {
+	segments, err := createSegments(offer.Itineraries[0].Segments)
+	if err != nil {
+		return entity.Flight{}, err
+	}
+
+	checkedBags, cabinBags, fareBrand := fareDetails(offer)
+	return entity.Flight{
+		ProviderName:    providerName,
+		Ref:             &entity.ProviderRef{ID: offer.ID},
+		Raw:             offer.Raw,
+		Price:           price,
+		DurationMinutes: durationToMinutes(offer.Itineraries[0].Duration),
+		Stops:           countStops(offer.Itineraries[0].Segments),
+		LayoverMinutes:  helper.LayoverMinutes(segments),
+		Segments:        segments,
+		CheckedBags:     checkedBags,
+		CabinBags:       cabinBags,
+		FareBrand:       fareBrand,
+	}, nil
+}
 
-	for _, s := range offer.Itineraries[0].Segments {
+// fareDetails returns the bags included in every segment and the fare brand
+// of the first traveler, the search is for one adult
+func fareDetails(offer entity.FlightOffer) (checked, cabin *entity.Baggage, brand string) {
+	if len(offer.TravelerPricings) == 0 || len(offer.TravelerPricings[0].FareDetailsBySegment) == 0 {
+		return nil, nil, ""
+	}
+
+	details := offer.TravelerPricings[0].FareDetailsBySegment
+	checkedBags := make([]*entity.Baggage, 0, len(details))
+	cabinBags := make([]*entity.Baggage, 0, len(details))
+	brands := make([]string, 0, len(details))
+	for _, d := range details {
+		checkedBags = append(checkedBags, baggage(d.IncludedCheckedBags))
+		cabinBags = append(cabinBags, baggage(d.IncludedCabinBags))
+		if d.BrandedFareLabel != "" {
+			brands = append(brands, d.BrandedFareLabel)
+		} else {
+			brands = append(brands, d.BrandedFare)
+		}
+	}
+	return helper.CommonBaggage(checkedBags), helper.CommonBaggage(cabinBags), helper.FareBrand(brands)
+}
+
+func baggage(bags *entity.BagsAmadeus) *entity.Baggage {
+	if bags == nil {
+		return nil
+	}
+	return &entity.Baggage{Quantity: bags.Quantity, Weight: bags.Weight, WeightUnit: bags.WeightUnit}
+}
+
+// itinerariesSegments iterate over itineraries just in case it has more than one item
+func itinerariesSegments(itineraries []entity.ItinerariesAmadeus) ([]entity.Segment, error) {
+	segments := make([]entity.Segment, 0)
+	for _, it := range itineraries {
+		itSegments, err := createSegments(it.Segments)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, itSegments...)
+	}
+	return segments, nil
+}
+
+// createSegments converts the segments, amadeus sends the local time of each airport without offset
+func createSegments(segmentsData []entity.SegmentAmadeus) ([]entity.Segment, error) {
+	segments := make([]entity.Segment, 0, len(segmentsData))
+	for _, s := range segmentsData {
+		departureTime, departureZone, err := helper.ParseAirportTime(timeLayout, s.Departure.At, s.Departure.IataCode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid departure time: %w", err)
+		}
+		arrivalTime, arrivalZone, err := helper.ParseAirportTime(timeLayout, s.Arrival.At, s.Arrival.IataCode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arrival time: %w", err)
+		}
 
 		segments = append(segments, entity.Segment{
 			DepartureAirport:   s.Departure.IataCode,
-			DepartureTime:      s.Departure.At,
+			DepartureTime:      departureTime,
 			DestinationAirport: s.Arrival.IataCode,
-			ArrivalTime:        s.Arrival.At,
+			ArrivalTime:        arrivalTime,
+			MarketingCarrier:   s.CarrierCode,
+			OperatingCarrier:   s.Operating.CarrierCode,
+			FlightNumber:       s.Number,
+			Aircraft:           s.Aircraft.Code,
+			TimeZoneUnknown:    !departureZone || !arrivalZone,
 		})
 	}
+	return segments, nil
+}
 
-	return entity.Flight{
-		ProviderName:    providerName,
-		Price:           price,
-		DurationMinutes: durationToMinutes(offer.Itineraries[0].Duration),
-		Segments:        segments,
+// countStops counts the connections plus the technical stops inside each segment
+func countStops(segments []entity.SegmentAmadeus) int {
+	if len(segments) == 0 {
+		return 0
+	}
+
+	stops := len(segments) - 1
+	for _, s := range segments {
+		stops += s.NumberOfStops
 	}
+	return stops
 }
 
 func durationToMinutes(duration string) int {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -85,6 +86,10 @@ func TestClient_GetFlights_Success(t *testing.T) {
 }
 
 func TestOffersPreProcessResponse(t *testing.T) {
+	var segment entity.SegmentAmadeus
+	segment.Departure.IataCode, segment.Departure.At = "MAD", "2024-01-01T08:00:00"
+	segment.Arrival.IataCode, segment.Arrival.At = "LIS", "2024-01-01T08:40:00"
+
 	offers := []entity.FlightOffer{
 		{
 			ID: "cheapest",
@@ -93,7 +98,7 @@ func TestOffersPreProcessResponse(t *testing.T) {
 				Currency string `json:"currency"`
 			}{Total: "100.00"},
 			Itineraries: []entity.ItinerariesAmadeus{
-				{Duration: "PT2H30M", Segments: []entity.SegmentAmadeus{}},
+				{Duration: "PT2H30M", Segments: []entity.SegmentAmadeus{segment}},
 			},
 		},
 		{
@@ -103,7 +108,7 @@ func TestOffersPreProcessResponse(t *testing.T) {
 				Currency string `json:"currency"`
 			}{Total: "150.00"},
 			Itineraries: []entity.ItinerariesAmadeus{
-				{Duration: "PT1H15M", Segments: []entity.SegmentAmadeus{}},
+				{Duration: "PT1H15M", Segments: []entity.SegmentAmadeus{segment}},
 			},
 		},
 	}
@@ -120,3 +125,188 @@ func TestOffersPreProcessResponse(t *testing.T) {
 		t.Errorf("Expected fastest duration 75m, got %v", resp.Fastest.DurationMinutes)
 	}
 }
+
+func TestCreateFlightFromOffer_CarrierAndStops(t *testing.T) {
+	var first, second entity.SegmentAmadeus
+	first.Departure.IataCode, first.Departure.At = "MAD", "2024-01-01T08:00:00"
+	first.Arrival.IataCode, first.Arrival.At = "LIS", "2024-01-01T08:40:00"
+	first.CarrierCode, first.Number = "TP", "1017"
+	first.Operating.CarrierCode = "NI"
+	first.Aircraft.Code = "E95"
+
+	second.Departure.IataCode, second.Departure.At = "LIS", "2024-01-01T10:10:00"
+	second.Arrival.IataCode, second.Arrival.At = "JFK", "2024-01-01T13:00:00"
+	second.CarrierCode, second.Number = "TP", "203"
+	second.NumberOfStops = 1
+
+	offer := entity.FlightOffer{
+		Itineraries: []entity.ItinerariesAmadeus{
+			{Duration: "PT10H", Segments: []entity.SegmentAmadeus{first, second}},
+		},
+	}
+
+	flight, err := createFlightFromOffer(offer, 500)
+	require.NoError(t, err)
+	require.Len(t, flight.Segments, 2)
+	assert.Equal(t, 2, flight.Stops)
+	assert.Equal(t, []int{90}, flight.LayoverMinutes)
+	assert.Equal(t, "TP", flight.Segments[0].MarketingCarrier)
+	assert.Equal(t, "NI", flight.Segments[0].OperatingCarrier)
+	assert.Equal(t, "1017", flight.Segments[0].FlightNumber)
+	assert.Equal(t, "E95", flight.Segments[0].Aircraft)
+	assert.Equal(t, "2024-01-01T08:00:00+01:00", flight.Segments[0].DepartureTime.Format(time.RFC3339))
+	assert.Equal(t, "2024-01-01T13:00:00-05:00", flight.Segments[1].ArrivalTime.Format(time.RFC3339))
+}
+
+func TestOffersPreProcessResponse_SkipsInvalidTimes(t *testing.T) {
+	var valid, invalid entity.SegmentAmadeus
+	valid.Departure.IataCode, valid.Departure.At = "MAD", "2024-01-01T08:00:00"
+	valid.Arrival.IataCode, valid.Arrival.At = "LIS", "2024-01-01T08:40:00"
+	invalid.Departure.IataCode, invalid.Departure.At = "MAD", "2024-01-01"
+	invalid.Arrival.IataCode, invalid.Arrival.At = "LIS", "2024-01-01T08:40:00"
+
+	offers := []entity.FlightOffer{
+		{ID: "invalid", Itineraries: []entity.ItinerariesAmadeus{{Duration: "PT1H", Segments: []entity.SegmentAmadeus{invalid}}}},
+		{ID: "valid", Itineraries: []entity.ItinerariesAmadeus{{Duration: "PT1H40M", Segments: []entity.SegmentAmadeus{valid}}}},
+	}
+	offers[0].Price.Total = "50"
+	offers[1].Price.Total = "100"
+
+	resp, err := offersPreProcessResponse(offers)
+	require.NoError(t, err)
+	require.Len(t, resp.Flights, 1)
+	assert.Equal(t, 100.0, resp.Cheapest.Price)
+	assert.False(t, resp.Cheapest.Segments[0].DepartureTime.IsZero())
+}
+
+func TestCreateFlightFromOffer_BaggageAndFareBrand(t *testing.T) {
+	var offer entity.FlightOffer
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "1",
+		"price": {"total": "180.00", "currency": "USD"},
+		"itineraries": [{"duration": "PT4H", "segments": [
+			{"departure": {"iataCode": "MAD", "at": "2024-01-01T08:00:00"}, "arrival": {"iataCode": "LIS", "at": "2024-01-01T08:40:00"}, "carrierCode": "TP", "number": "1017"},
+			{"departure": {"iataCode": "LIS", "at": "2024-01-01T10:00:00"}, "arrival": {"iataCode": "OPO", "at": "2024-01-01T11:00:00"}, "carrierCode": "TP", "number": "1944"}
+		]}],
+		"travelerPricings": [{"travelerId": "1", "fareDetailsBySegment": [
+			{"segmentId": "1", "cabin": "ECONOMY", "brandedFare": "CLASSIC", "brandedFareLabel": "ECONOMY CLASSIC", "includedCheckedBags": {"quantity": 1}, "includedCabinBags": {"quantity": 1}},
+			{"segmentId": "2", "cabin": "ECONOMY", "brandedFare": "CLASSIC", "brandedFareLabel": "ECONOMY CLASSIC", "includedCheckedBags": {"weight": 23, "weightUnit": "KG"}, "includedCabinBags": {"quantity": 1}}
+		]}]
+	}`), &offer))
+
+	flight, err := createFlightFromOffer(offer, 180)
+	require.NoError(t, err)
+	assert.Equal(t, &entity.Baggage{Weight: 23, WeightUnit: "KG"}, flight.CheckedBags)
+	assert.True(t, flight.CheckedBags.Included())
+	assert.Equal(t, &entity.Baggage{Quantity: 1}, flight.CabinBags)
+	assert.Equal(t, "ECONOMY CLASSIC", flight.FareBrand)
+}
+
+func TestOffersPreProcessResponse_UnknownBaggage(t *testing.T) {
+	var segment entity.SegmentAmadeus
+	segment.Departure.IataCode, segment.Departure.At = "MAD", "2024-01-01T08:00:00"
+	segment.Arrival.IataCode, segment.Arrival.At = "LIS", "2024-01-01T08:40:00"
+
+	offer := entity.FlightOffer{
+		ID:          "basic",
+		Itineraries: []entity.ItinerariesAmadeus{{Duration: "PT1H40M", Segments: []entity.SegmentAmadeus{segment}}},
+		TravelerPricings: []entity.TravelerPricingAmadeus{{FareDetailsBySegment: []entity.FareDetailsAmadeus{
+			{SegmentID: "1", BrandedFare: "LIGHT", IncludedCheckedBags: &entity.BagsAmadeus{Quantity: 0}},
+		}}},
+	}
+	offer.Price.Total = "90"
+
+	resp, err := offersPreProcessResponse([]entity.FlightOffer{offer})
+	require.NoError(t, err)
+	require.Len(t, resp.Flights, 1)
+	assert.Equal(t, &entity.Baggage{}, resp.Flights[0].CheckedBags)
+	assert.False(t, resp.Flights[0].CheckedBags.Included())
+	assert.Nil(t, resp.Flights[0].CabinBags)
+	assert.Equal(t, "LIGHT", resp.Flights[0].FareBrand)
+}
+
+func TestOffersPreProcessResponse_KeepsUnknownAirports(t *testing.T) {
+	var segment entity.SegmentAmadeus
+	segment.Departure.IataCode, segment.Departure.At = "MAD", "2024-01-01T08:00:00"
+	segment.Arrival.IataCode, segment.Arrival.At = "QQQ", "2024-01-01T10:40:00"
+
+	offer := entity.FlightOffer{ID: "unknown", Itineraries: []entity.ItinerariesAmadeus{{Duration: "PT3H40M", Segments: []entity.SegmentAmadeus{segment}}}}
+	offer.Price.Total = "100"
+
+	resp, err := offersPreProcessResponse([]entity.FlightOffer{offer})
+	require.NoError(t, err)
+	require.Len(t, resp.Flights, 1)
+	assert.Equal(t, "2024-01-01T08:00:00+01:00", resp.Flights[0].Segments[0].DepartureTime.Format(time.RFC3339))
+	assert.Equal(t, "2024-01-01T10:40:00Z", resp.Flights[0].Segments[0].ArrivalTime.Format(time.RFC3339))
+	assert.True(t, resp.Flights[0].Segments[0].TimeZoneUnknown)
+}
+
+func TestClient_PriceOffer(t *testing.T) {
+	const searchOffer = `{"id": "1", "source": "GDS", "price": {"total": "200.00", "currency": "USD"}, "itineraries": [{"duration": "PT2H30M", "segments": [
+		{"departure": {"iataCode": "JFK", "at": "2024-01-01T10:00:00"}, "arrival": {"iataCode": "LAX", "at": "2024-01-01T12:30:00"}, "carrierCode": "AA", "number": "1"}
+	]}]}`
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/security/oauth2/token":
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "test-token"})
+
+		case r.URL.Path == "/v2/shopping/flight-offers":
+			w.Write([]byte(`{"data": [` + searchOffer + `]}`))
+
+		case r.URL.Path == "/v1/shopping/flight-offers/pricing" && r.Method == http.MethodPost:
+			assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+			assert.Equal(t, http.MethodGet, r.Header.Get("X-HTTP-Method-Override"))
+
+			// the offer is sent as amadeus returned it, with the fields that are not mapped
+			var body struct {
+				Data struct {
+					Type         string                   `json:"type"`
+					FlightOffers []map[string]interface{} `json:"flightOffers"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "flight-offers-pricing", body.Data.Type)
+			require.Len(t, body.Data.FlightOffers, 1)
+			assert.Equal(t, "GDS", body.Data.FlightOffers[0]["source"])
+
+			w.Write([]byte(`{"data": {"type": "flight-offers-pricing", "flightOffers": [{"id": "1", "lastTicketingDate": "2023-12-20",
+				"price": {"total": "215.40", "currency": "USD"},
+				"fareRules": {"rules": [{"category": "EXCHANGE", "maxPenaltyAmount": "50.00"}, {"category": "REFUND", "notApplicable": true}]}}]}}`))
+
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer testServer.Close()
+
+	client := NewClient(http.Client{}, entity.Provider{BaseURL: testServer.URL, Apikey: "test-api-key", Secret: "test-secret"})
+	ctx := context.Background()
+
+	result, err := client.GetFlights(ctx, entity.FlightSearchParam{Origin: "JFK", Destination: "LAX", DateDeparture: "2024-01-01"})
+	require.NoError(t, err)
+	require.Len(t, result.Flights, 1)
+	flight := result.Flights[0]
+	assert.Equal(t, &entity.ProviderRef{ID: "1"}, flight.Ref)
+	assert.Equal(t, flight.Raw, result.Cheapest.Raw)
+
+	offer := entity.StoredOffer{Ref: entity.ProviderRef{Provider: providerName, ID: "1", Token: "token"}, Price: flight.Price, Raw: flight.Raw}
+	priced, err := client.PriceOffer(ctx, offer)
+	require.NoError(t, err)
+	assert.Equal(t, entity.PricedOffer{
+		Provider:          providerName,
+		Token:             "token",
+		Currency:          "USD",
+		PreviousPrice:     200,
+		Price:             215.4,
+		PriceChange:       15.4,
+		LastTicketingDate: "2023-12-20",
+		FareRules: []entity.FareRule{
+			{Category: "EXCHANGE", Allowed: true, MaxPenalty: 50},
+			{Category: "REFUND", Allowed: false},
+		},
+	}, priced)
+
+	_, err = client.PriceOffer(ctx, entity.StoredOffer{Ref: offer.Ref})
+	assert.ErrorIs(t, err, providers.ErrOfferNotFound)
+}
@@ -0,0 +1,54 @@
+package amadeus
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/mariajdab/flight-price/internal/providers/providertest"
+)
+
+func TestContract(t *testing.T) {
+	providertest.Run(t, providertest.Stub{
+		New: func(baseURL string) providers.Flight {
+			return NewAdapterAmadeus(NewClient(http.Client{}, entity.Provider{
+				BaseURL: baseURL,
+				Apikey:  "test-api-key",
+				Secret:  "test-secret",
+				Timeout: time.Second,
+			}))
+		},
+		Criteria: entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2025-06-01"},
+		Handler: func(t *testing.T, search providertest.Response) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/security/oauth2/token":
+					providertest.Write(w, r, providertest.Response{Status: http.StatusOK, Body: `{"access_token": "test-token"}`})
+				case "/v2/shopping/flight-offers":
+					providertest.Write(w, r, search)
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			})
+		},
+		Flights: `{"data": [
+			{"id": "1", "price": {"total": "120.50", "currency": "USD"}, "itineraries": [{"duration": "PT1H20M", "segments": [
+				{"departure": {"iataCode": "MAD", "at": "2025-06-01T07:00:00"}, "arrival": {"iataCode": "LIS", "at": "2025-06-01T07:20:00"}, "carrierCode": "TP", "number": "1017"}
+			]}]},
+			{"id": "2", "price": {"total": "95.00", "currency": "USD"}, "itineraries": [{"duration": "PT4H", "segments": [
+				{"departure": {"iataCode": "MAD", "at": "2025-06-01T09:00:00"}, "arrival": {"iataCode": "OPO", "at": "2025-06-01T09:15:00"}, "carrierCode": "IB", "number": "3100"},
+				{"departure": {"iataCode": "OPO", "at": "2025-06-01T11:00:00"}, "arrival": {"iataCode": "LIS", "at": "2025-06-01T12:00:00"}, "carrierCode": "TP", "number": "1941"}
+			]}]}
+		]}`,
+		Empty: `{"data": []}`,
+		MissingLegs: `{"data": [
+			{"id": "1", "price": {"total": "50.00"}, "itineraries": []},
+			{"id": "2", "price": {"total": "60.00"}, "itineraries": [{"duration": "PT1H", "segments": []}]},
+			{"id": "3", "price": {"total": "120.50"}, "itineraries": [{"duration": "PT1H20M", "segments": [
+				{"departure": {"iataCode": "MAD", "at": "2025-06-01T07:00:00"}, "arrival": {"iataCode": "LIS", "at": "2025-06-01T07:20:00"}, "carrierCode": "TP", "number": "1017"}
+			]}]}
+		]}`,
+	})
+}
@@ -0,0 +1,371 @@
+package duffel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mariajdab/flight-price/helper"
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+// this client use the duffel offer requests API, the search creates an offer
+// request and then retrieves its offers page by page
+const (
+	providerName = "duffel"
+	apiVersion   = "v2"
+	timeLayout   = "2006-01-02T15:04:05"
+	// maxOfferPages limits the pages retrieved of an offer request
+	maxOfferPages = 3
+	offersPerPage = "50"
+)
+
+// isoDuration matches the durations of the slices, e.g. PT2H30M or P1DT2H
+var isoDuration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?)?$`)
+
+type Client struct {
+	httpClient http.Client
+	baseURL    string
+	apikey     string
+	timeout    time.Duration
+}
+
+func NewClient(httpClient http.Client, configProvider entity.Provider) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    configProvider.BaseURL,
+		apikey:     configProvider.Apikey,
+		timeout:    configProvider.Timeout,
+	}
+}
+
+func (c *Client) GetFlights(ctx context.Context, params entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	offerRequestID, err := c.createOfferRequest(ctx, params)
+	if err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("error in createOfferRequest: %w", err)
+	}
+
+	offers, err := c.getOffers(ctx, offerRequestID)
+	if err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("error in getOffers: %w", err)
+	}
+
+	resp, err := offersPreProcess(offers)
+	if err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("error in offersPreProcess: %w", err)
+	}
+
+	return resp, nil
+}
+
+// createOfferRequest creates the search without the offers, they are retrieved with the returned id
+func (c *Client) createOfferRequest(ctx context.Context, params entity.FlightSearchParam) (string, error) {
+	const offerRequestEndpoint = "air/offer_requests"
+
+	baseURL, err := url.Parse(fmt.Sprintf("%s/%s", c.baseURL, offerRequestEndpoint))
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("return_offers", "false")
+	baseURL.RawQuery = query.Encode()
+
+	var body struct {
+		Data entity.OfferRequestDuffel `json:"data"`
+	}
+	body.Data = entity.OfferRequestDuffel{
+		Slices: []entity.SliceRequestDuffel{{
+			Origin:        params.Origin,
+			Destination:   params.Destination,
+			DepartureDate: params.DateDeparture,
+		}},
+		Passengers: []entity.PassengerDuffel{{Type: "adult"}},
+		CabinClass: "economy",
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL.String(), bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to create offer request (status %d): %s", resp.StatusCode, string(errorBody))
+	}
+
+	var offerRequest entity.OfferRequestDuffelResp
+	if err := json.NewDecoder(resp.Body).Decode(&offerRequest); err != nil {
+		log.Println("internal error during decode response from duffel provider", err)
+		return "", err
+	}
+	if offerRequest.Data.ID == "" {
+		return "", errors.New("the offer request does not have id")
+	}
+
+	return offerRequest.Data.ID, nil
+}
+
+// getOffers retrieves the offers of the request cheapest first, following the
+// pages until the last one or maxOfferPages
+func (c *Client) getOffers(ctx context.Context, offerRequestID string) ([]entity.OfferDuffel, error) {
+	var offers []entity.OfferDuffel
+
+	after := ""
+	for range maxOfferPages {
+		page, err := c.getOffersPage(ctx, offerRequestID, after)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, page.Data...)
+
+		if page.Meta.After == "" {
+			break
+		}
+		after = page.Meta.After
+	}
+
+	return offers, nil
+}
+
+func (c *Client) getOffersPage(ctx context.Context, offerRequestID, after string) (entity.OffersDuffelResp, error) {
+	const offersEndpoint = "air/offers"
+
+	baseURL, err := url.Parse(fmt.Sprintf("%s/%s", c.baseURL, offersEndpoint))
+	if err != nil {
+		return entity.OffersDuffelResp{}, err
+	}
+
+	query := url.Values{}
+	query.Set("offer_request_id", offerRequestID)
+	query.Set("sort", "total_amount")
+	query.Set("limit", offersPerPage)
+	if after != "" {
+		query.Set("after", after)
+	}
+	baseURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL.String(), nil)
+	if err != nil {
+		return entity.OffersDuffelResp{}, err
+	}
+	c.setHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return entity.OffersDuffelResp{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return entity.OffersDuffelResp{}, fmt.Errorf("failed to get offers (status %d): %s", resp.StatusCode, string(errorBody))
+	}
+
+	var page entity.OffersDuffelResp
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		log.Println("internal error during decode response from duffel provider", err)
+		return entity.OffersDuffelResp{}, err
+	}
+
+	return page, nil
+}
+
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Duffel-Version", apiVersion)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apikey))
+}
+
+func offersPreProcess(offers []entity.OfferDuffel) (entity.FlightSearchResponse, error) {
+	if len(offers) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("empty offers list from duffel")
+	}
+
+	var cheapest, fastest entity.Flight
+
+	resp := entity.FlightSearchResponse{
+		Flights: make([]entity.Flight, 0, len(offers)),
+	}
+
+	for _, offer := range offers {
+		// duffel prices in the currency of the airline, they can't be compared with the others
+		if offer.TotalCurrency != entity.DefaultCurrency {
+			log.Printf("warning: skipping duffel offer %v in %s", offer.ID, offer.TotalCurrency)
+			continue
+		}
+
+		flight, err := createFlightFromOffer(offer)
+		if err != nil {
+			log.Printf("warning: skipping duffel offer %v: %v", offer.ID, err)
+			continue
+		}
+		if len(flight.Segments) == 0 {
+			log.Printf("warning: skipping duffel offer %v without segments", offer.ID)
+			continue
+		}
+
+		// check cheapest and fastest flight, the first valid one is the initial value
+		if len(resp.Flights) == 0 || flight.Price < cheapest.Price {
+			cheapest = flight
+		}
+		if len(resp.Flights) == 0 || flight.DurationMinutes < fastest.DurationMinutes {
+			fastest = flight
+		}
+
+		resp.Flights = append(resp.Flights, flight)
+	}
+
+	if len(resp.Flights) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("no valid offers from duffel")
+	}
+
+	resp.Provider = providerName
+	resp.Currency = entity.DefaultCurrency
+	resp.Cheapest = cheapest
+	resp.Fastest = fastest
+
+	return resp, nil
+}
+
+// createFlightFromOffer converts the first slice of the offer, the one way trip
+func createFlightFromOffer(offer entity.OfferDuffel) (entity.Flight, error) {
+	price, err := strconv.ParseFloat(offer.TotalAmount, 64)
+	if err != nil {
+		return entity.Flight{}, fmt.Errorf("invalid price: %w", err)
+	}
+	if len(offer.Slices) == 0 {
+		return entity.Flight{ProviderName: providerName, Price: price}, nil
+	}
+
+	segments, err := createSegments(offer.Slices[0].Segments)
+	if err != nil {
+		return entity.Flight{}, err
+	}
+
+	// the duration is the span of the segments, their times have the time zone of each
+	// airport. Without the time zone of an airport the duration of the slice is used
+	duration := 0
+	if len(segments) > 0 {
+		duration = int(segments[len(segments)-1].ArrivalTime.Sub(segments[0].DepartureTime).Minutes())
+	}
+	if slices.ContainsFunc(segments, func(s entity.Segment) bool { return s.TimeZoneUnknown }) {
+		duration = durationMinutes(offer.Slices[0].Duration)
+	}
+
+	return entity.Flight{
+		ProviderName:    providerName,
+		Price:           price,
+		DurationMinutes: duration,
+		Stops:           max(len(segments)-1, 0),
+		LayoverMinutes:  helper.LayoverMinutes(segments),
+		Segments:        segments,
+		Ref:             &entity.ProviderRef{ID: offer.ID},
+		Raw:             offer.Raw,
+		CheckedBags:     helper.CommonBaggage(segmentBags(offer.Slices[0].Segments, "checked")),
+		CabinBags:       helper.CommonBaggage(segmentBags(offer.Slices[0].Segments, "carry_on")),
+		FareBrand:       offer.Slices[0].FareBrandName,
+	}, nil
+}
+
+// segmentBags returns the bags of the type included for the first passenger in
+// each segment, nil for the segments without passengers
+func segmentBags(segments []entity.SegmentDuffel, bagType string) []*entity.Baggage {
+	bags := make([]*entity.Baggage, 0, len(segments))
+	for _, s := range segments {
+		if len(s.Passengers) == 0 {
+			bags = append(bags, nil)
+			continue
+		}
+
+		b := &entity.Baggage{}
+		for _, baggage := range s.Passengers[0].Baggages {
+			if baggage.Type == bagType {
+				b.Quantity += baggage.Quantity
+			}
+		}
+		bags = append(bags, b)
+	}
+	return bags
+}
+
+func createSegments(segmentsData []entity.SegmentDuffel) ([]entity.Segment, error) {
+	segments := make([]entity.Segment, 0, len(segmentsData))
+	for _, s := range segmentsData {
+		departureTime, departureZone, err := segmentTime(s.DepartingAt, s.Origin.IataCode, s.Origin.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid departure time: %w", err)
+		}
+		arrivalTime, arrivalZone, err := segmentTime(s.ArrivingAt, s.Destination.IataCode, s.Destination.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arrival time: %w", err)
+		}
+
+		segments = append(segments, entity.Segment{
+			DepartureAirport:   s.Origin.IataCode,
+			DepartureTime:      departureTime,
+			DestinationAirport: s.Destination.IataCode,
+			ArrivalTime:        arrivalTime,
+			MarketingCarrier:   s.MarketingCarrier.IataCode,
+			OperatingCarrier:   s.OperatingCarrier.IataCode,
+			FlightNumber:       s.MarketingCarrierFlightNumber,
+			Aircraft:           s.Aircraft.IataCode,
+			TimeZoneUnknown:    !departureZone || !arrivalZone,
+		})
+	}
+	return segments, nil
+}
+
+// segmentTime parses the local time of the airport with the time zone that
+// duffel sends with it, the dataset of the airports is only the fallback
+func segmentTime(value, airport, timeZone string) (time.Time, bool, error) {
+	if timeZone != "" {
+		if loc, err := time.LoadLocation(timeZone); err == nil {
+			t, err := time.ParseInLocation(timeLayout, value, loc)
+			return t, err == nil, err
+		}
+		log.Printf("warning: unknown duffel time zone %s for the airport %s", timeZone, airport)
+	}
+	return helper.ParseAirportTime(timeLayout, value, airport)
+}
+
+// durationMinutes converts an ISO 8601 duration like P1DT2H30M, 0 when it is not valid
+func durationMinutes(value string) int {
+	matches := isoDuration.FindStringSubmatch(value)
+	if matches == nil {
+		log.Printf("warning: could not parse the duffel duration: %s", value)
+		return 0
+	}
+
+	minutes := 0
+	for i, unit := range []int{24 * 60, 60, 1} {
+		if matches[i+1] != "" {
+			n, _ := strconv.Atoi(matches[i+1])
+			minutes += n * unit
+		}
+	}
+	return minutes
+}
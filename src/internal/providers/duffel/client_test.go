@@ -0,0 +1,110 @@
+package duffel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const offerBody = `{"id": %q, "total_amount": %q, "total_currency": %q, "slices": [{"duration": "PT1H20M", "fare_brand_name": "Basic", "segments": [
+	{"origin": {"iata_code": "MAD"}, "destination": {"iata_code": "LIS"}, "departing_at": "2025-06-01T07:00:00", "arriving_at": "2025-06-01T07:20:00", "marketing_carrier": {"iata_code": "TP"}, "operating_carrier": {"iata_code": "TP"}, "marketing_carrier_flight_number": "1017", "aircraft": {"iata_code": "320"},
+	 "passengers": [{"passenger_id": "pas_1", "baggages": [{"type": "carry_on", "quantity": 1}]}]}
+]}]}`
+
+func TestClient_GetFlights_RetrievesEveryPage(t *testing.T) {
+	var pages []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer duffel_test_api_key", r.Header.Get("Authorization"))
+		assert.Equal(t, apiVersion, r.Header.Get("Duffel-Version"))
+
+		switch r.URL.Path {
+		case "/air/offer_requests":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "false", r.URL.Query().Get("return_offers"))
+
+			var body struct {
+				Data entity.OfferRequestDuffel `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Len(t, body.Data.Slices, 1)
+			assert.Equal(t, entity.SliceRequestDuffel{Origin: "MAD", Destination: "LIS", DepartureDate: "2025-06-01"}, body.Data.Slices[0])
+			assert.Equal(t, []entity.PassengerDuffel{{Type: "adult"}}, body.Data.Passengers)
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data": {"id": "orq_1"}}`))
+		case "/air/offers":
+			query := r.URL.Query()
+			assert.Equal(t, "orq_1", query.Get("offer_request_id"))
+			pages = append(pages, query.Get("after"))
+
+			if query.Get("after") == "" {
+				_, _ = w.Write([]byte(`{"meta": {"after": "page2"}, "data": [` + offer("off_1", "120.50", "USD") + `, ` + offer("off_eur", "80.00", "EUR") + `]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"meta": {"after": null}, "data": [` + offer("off_2", "99.00", "USD") + `]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer testServer.Close()
+
+	client := NewClient(http.Client{}, entity.Provider{
+		BaseURL: testServer.URL,
+		Apikey:  "duffel_test_api_key",
+		Timeout: time.Second,
+	})
+
+	resp, err := client.GetFlights(context.Background(), entity.FlightSearchParam{
+		Origin:        "MAD",
+		Destination:   "LIS",
+		DateDeparture: "2025-06-01",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "page2"}, pages)
+
+	// the offer in euros is skipped, the prices must be comparable
+	require.Len(t, resp.Flights, 2)
+	assert.Equal(t, "off_1", resp.Flights[0].Ref.ID)
+	assert.Equal(t, "off_2", resp.Flights[1].Ref.ID)
+	assert.Equal(t, "off_2", resp.Cheapest.Ref.ID)
+	assert.Contains(t, string(resp.Flights[0].Raw), `"total_amount": "120.50"`)
+	assert.Equal(t, 80, resp.Flights[0].DurationMinutes)
+	assert.Equal(t, "320", resp.Flights[0].Segments[0].Aircraft)
+
+	// a bag type missing in the passenger baggages is not included
+	assert.Equal(t, &entity.Baggage{}, resp.Flights[0].CheckedBags)
+	assert.Equal(t, &entity.Baggage{Quantity: 1}, resp.Flights[0].CabinBags)
+	assert.Equal(t, "Basic", resp.Flights[0].FareBrand)
+}
+
+func offer(id, amount, currency string) string {
+	return fmt.Sprintf(offerBody, id, amount, currency)
+}
+
+func TestCreateSegments_UsesTheTimeZoneOfDuffel(t *testing.T) {
+	var segment entity.SegmentDuffel
+	segment.Origin = entity.PlaceDuffel{IataCode: "QQQ", TimeZone: "Asia/Tokyo"}
+	segment.Destination = entity.PlaceDuffel{IataCode: "QQR"}
+	segment.DepartingAt, segment.ArrivingAt = "2025-06-01T07:00:00", "2025-06-01T09:00:00"
+
+	segments, err := createSegments([]entity.SegmentDuffel{segment})
+	require.NoError(t, err)
+	assert.Equal(t, "2025-06-01T07:00:00+09:00", segments[0].DepartureTime.Format(time.RFC3339))
+	// an airport without time zone keeps its local clock and the segment is marked
+	assert.Equal(t, "2025-06-01T09:00:00Z", segments[0].ArrivalTime.Format(time.RFC3339))
+	assert.True(t, segments[0].TimeZoneUnknown)
+}
+
+func TestDurationMinutes(t *testing.T) {
+	assert.Equal(t, 160, durationMinutes("PT2H40M"))
+	assert.Equal(t, 1500, durationMinutes("P1DT1H"))
+	assert.Equal(t, 0, durationMinutes("invalid"))
+}
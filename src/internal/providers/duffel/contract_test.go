@@ -0,0 +1,53 @@
+package duffel
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/mariajdab/flight-price/internal/providers/providertest"
+)
+
+func TestContract(t *testing.T) {
+	providertest.Run(t, providertest.Stub{
+		New: func(baseURL string) providers.Flight {
+			return NewAdapterDuffel(NewClient(http.Client{}, entity.Provider{
+				BaseURL: baseURL,
+				Apikey:  "duffel_test_api_key",
+				Timeout: time.Second,
+			}))
+		},
+		Criteria: entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2025-06-01"},
+		Handler: func(t *testing.T, search providertest.Response) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/air/offer_requests":
+					providertest.Write(w, r, providertest.Response{Status: http.StatusCreated, Body: `{"data": {"id": "orq_1"}}`})
+				case r.Method == http.MethodGet && r.URL.Path == "/air/offers":
+					providertest.Write(w, r, search)
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			})
+		},
+		Flights: `{"meta": {"after": null}, "data": [
+			{"id": "off_1", "total_amount": "120.50", "total_currency": "USD", "slices": [{"duration": "PT1H20M", "segments": [
+				{"origin": {"iata_code": "MAD"}, "destination": {"iata_code": "LIS"}, "departing_at": "2025-06-01T07:00:00", "arriving_at": "2025-06-01T07:20:00", "marketing_carrier": {"iata_code": "TP"}, "marketing_carrier_flight_number": "1017"}
+			]}]},
+			{"id": "off_2", "total_amount": "95.00", "total_currency": "USD", "slices": [{"duration": "PT4H", "segments": [
+				{"origin": {"iata_code": "MAD"}, "destination": {"iata_code": "OPO"}, "departing_at": "2025-06-01T09:00:00", "arriving_at": "2025-06-01T09:15:00", "marketing_carrier": {"iata_code": "IB"}, "marketing_carrier_flight_number": "3100"},
+				{"origin": {"iata_code": "OPO"}, "destination": {"iata_code": "LIS"}, "departing_at": "2025-06-01T11:00:00", "arriving_at": "2025-06-01T12:00:00", "marketing_carrier": {"iata_code": "TP"}, "marketing_carrier_flight_number": "1941"}
+			]}]}
+		]}`,
+		Empty: `{"meta": {"after": null}, "data": []}`,
+		MissingLegs: `{"meta": {"after": null}, "data": [
+			{"id": "off_1", "total_amount": "50.00", "total_currency": "USD", "slices": []},
+			{"id": "off_2", "total_amount": "60.00", "total_currency": "USD", "slices": [{"duration": "PT1H", "segments": []}]},
+			{"id": "off_3", "total_amount": "120.50", "total_currency": "USD", "slices": [{"duration": "PT1H20M", "segments": [
+				{"origin": {"iata_code": "MAD"}, "destination": {"iata_code": "LIS"}, "departing_at": "2025-06-01T07:00:00", "arriving_at": "2025-06-01T07:20:00", "marketing_carrier": {"iata_code": "TP"}, "marketing_carrier_flight_number": "1017"}
+			]}]}
+		]}`,
+	})
+}
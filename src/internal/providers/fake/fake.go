@@ -0,0 +1,194 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mariajdab/flight-price/helper"
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const providerName = "Fake"
+
+var (
+	carriers = []string{"IB", "TP", "LH", "AF", "KL", "BA", "UX", "VY"}
+	hubs     = []string{"FRA", "AMS", "CDG", "LHR", "MUC", "MAD"}
+)
+
+// Provider serves flights without calling any API, to run the server and the tests
+// offline. The flights are read from the fixtures directory when it has a file for
+// the search, otherwise they are generated from the search so every call returns
+// the same flights
+type Provider struct {
+	name        string
+	fixturesDir string
+}
+
+// New creates a fake provider, an empty fixturesDir always generates the flights
+func New(name, fixturesDir string) *Provider {
+	if name == "" {
+		name = providerName
+	}
+	return &Provider{name: name, fixturesDir: fixturesDir}
+}
+
+func (p *Provider) Name() string {
+	return p.name
+}
+
+func (p *Provider) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return entity.FlightSearchResponse{}, err
+	}
+
+	resp, found, err := p.fixture(criteria)
+	if err != nil {
+		return entity.FlightSearchResponse{}, err
+	}
+	if !found {
+		resp, err = p.generate(criteria)
+		if err != nil {
+			return entity.FlightSearchResponse{}, err
+		}
+	}
+
+	if len(resp.Flights) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("empty offers list")
+	}
+
+	resp.Provider = p.name
+	if resp.Currency == "" {
+		resp.Currency = entity.DefaultCurrency
+	}
+	for i := range resp.Flights {
+		resp.Flights[i].ProviderName = p.name
+		if resp.Flights[i].LayoverMinutes == nil {
+			resp.Flights[i].LayoverMinutes = helper.LayoverMinutes(resp.Flights[i].Segments)
+		}
+	}
+	resp.Cheapest, resp.Fastest = cheapestAndFastest(resp.Flights)
+	return resp, nil
+}
+
+// fixture reads <origin>-<destination>-<date>.json or <origin>-<destination>.json,
+// with the names in lower case and the spaces replaced by underscores. The file
+// is a provider response: {"flights": [...]}
+func (p *Provider) fixture(criteria entity.FlightSearchParam) (entity.FlightSearchResponse, bool, error) {
+	if p.fixturesDir == "" {
+		return entity.FlightSearchResponse{}, false, nil
+	}
+
+	route := fixtureName(criteria.Origin) + "-" + fixtureName(criteria.Destination)
+	for _, name := range []string{route + "-" + criteria.DateDeparture + ".json", route + ".json"} {
+		data, err := os.ReadFile(filepath.Join(p.fixturesDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return entity.FlightSearchResponse{}, false, err
+		}
+
+		var resp entity.FlightSearchResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return entity.FlightSearchResponse{}, false, fmt.Errorf("invalid fixture %s: %w", name, err)
+		}
+		return resp, true, nil
+	}
+	return entity.FlightSearchResponse{}, false, nil
+}
+
+func fixtureName(city string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(city)), " ", "_")
+}
+
+// generate builds between 3 and 6 flights of the route, the random numbers
+// are seeded with the search and the name of the provider
+func (p *Provider) generate(criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	origin := helper.CityToIATACode(criteria.Origin)
+	destination := helper.CityToIATACode(criteria.Destination)
+	if origin == "" || destination == "" {
+		return entity.FlightSearchResponse{}, errors.New("origin or destination not supported")
+	}
+
+	date, err := time.Parse("2006-01-02", criteria.DateDeparture)
+	if err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("invalid departure date: %w", err)
+	}
+
+	seed := fnv.New64a()
+	fmt.Fprintf(seed, "%s|%s|%s|%s", p.name, origin, destination, criteria.DateDeparture)
+	rng := rand.New(rand.NewPCG(seed.Sum64(), 0))
+
+	resp := entity.FlightSearchResponse{Currency: entity.DefaultCurrency}
+	count := 3 + rng.IntN(4)
+	for range count {
+		carrier := carriers[rng.IntN(len(carriers))]
+		departure := time.Date(date.Year(), date.Month(), date.Day(), 6+rng.IntN(16), 5*rng.IntN(12), 0, 0, airportLocation(origin))
+
+		route := []string{origin, destination}
+		if rng.IntN(3) == 0 {
+			if hub := hubs[rng.IntN(len(hubs))]; hub != origin && hub != destination {
+				route = []string{origin, hub, destination}
+			}
+		}
+
+		segments := make([]entity.Segment, 0, len(route)-1)
+		at := departure
+		for i := 1; i < len(route); i++ {
+			if i > 1 {
+				at = at.Add(time.Duration(45+5*rng.IntN(24)) * time.Minute)
+			}
+			arrival := at.Add(time.Duration(50+5*rng.IntN(36)) * time.Minute)
+			segments = append(segments, entity.Segment{
+				DepartureAirport:   route[i-1],
+				DestinationAirport: route[i],
+				DepartureTime:      at.In(airportLocation(route[i-1])),
+				ArrivalTime:        arrival.In(airportLocation(route[i])),
+				MarketingCarrier:   carrier,
+				OperatingCarrier:   carrier,
+				FlightNumber:       fmt.Sprintf("%d", 100+rng.IntN(9000)),
+				Aircraft:           "320",
+			})
+			at = arrival
+		}
+
+		price := 40 + rng.Float64()*360 + float64(len(segments)-1)*30
+		resp.Flights = append(resp.Flights, entity.Flight{
+			Price:           math.Round(price*100) / 100,
+			DurationMinutes: int(at.Sub(departure).Minutes()),
+			Stops:           len(segments) - 1,
+			Segments:        segments,
+		})
+	}
+	return resp, nil
+}
+
+func airportLocation(code string) *time.Location {
+	loc, err := helper.AirportLocation(code)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func cheapestAndFastest(flights []entity.Flight) (entity.Flight, entity.Flight) {
+	cheapest, fastest := flights[0], flights[0]
+	for _, f := range flights[1:] {
+		if f.Price < cheapest.Price {
+			cheapest = f
+		}
+		if f.DurationMinutes < fastest.DurationMinutes {
+			fastest = f
+		}
+	}
+	return cheapest, fastest
+}
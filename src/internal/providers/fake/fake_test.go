@@ -0,0 +1,63 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers/providertest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvider_GeneratesTheSameFlights(t *testing.T) {
+	criteria := entity.FlightSearchParam{Origin: "Madrid", Destination: "Berlin", DateDeparture: "2025-06-01"}
+
+	first, err := New("", "").SearchFlights(context.Background(), criteria)
+	require.NoError(t, err)
+	second, err := New("", "").SearchFlights(context.Background(), criteria)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, providerName, first.Provider)
+	require.GreaterOrEqual(t, len(first.Flights), 3)
+	providertest.CheckResponse(t, first)
+
+	for _, f := range first.Flights {
+		assert.Equal(t, providerName, f.ProviderName)
+		assert.Equal(t, "MAD", f.Segments[0].DepartureAirport)
+		assert.Equal(t, "BER", f.Segments[len(f.Segments)-1].DestinationAirport)
+		assert.Equal(t, len(f.Segments)-1, f.Stops)
+	}
+
+	other, err := New("", "").SearchFlights(context.Background(), entity.FlightSearchParam{Origin: "Madrid", Destination: "Berlin", DateDeparture: "2025-06-02"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Flights, other.Flights)
+}
+
+func TestProvider_Fixtures(t *testing.T) {
+	provider := New("Local", "testdata")
+
+	resp, err := provider.SearchFlights(context.Background(), entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2025-06-01"})
+	require.NoError(t, err)
+	require.Len(t, resp.Flights, 2)
+	assert.Equal(t, "Local", resp.Flights[1].ProviderName)
+	assert.Equal(t, 89.99, resp.Cheapest.Price)
+	assert.Equal(t, 75, resp.Fastest.DurationMinutes)
+	providertest.CheckResponse(t, resp)
+
+	// the routes without fixture are generated
+	resp, err = provider.SearchFlights(context.Background(), entity.FlightSearchParam{Origin: "Lisbon", Destination: "Madrid", DateDeparture: "2025-06-01"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Flights)
+}
+
+func TestProvider_Errors(t *testing.T) {
+	_, err := New("", "").SearchFlights(context.Background(), entity.FlightSearchParam{Origin: "Atlantis", Destination: "Madrid", DateDeparture: "2025-06-01"})
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = New("", "").SearchFlights(ctx, entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2025-06-01"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -16,6 +16,10 @@ func NewAdapterGoogleFlight(client *Client) *GoogleFlight {
 	return &GoogleFlight{client: client}
 }
 
+func (p *GoogleFlight) Name() string {
+	return providerName
+}
+
 func (p *GoogleFlight) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
 	origin := helper.CityToGoogleCode(criteria.Origin)
 	destination := helper.CityToGoogleCode(criteria.Destination)
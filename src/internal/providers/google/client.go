@@ -7,16 +7,19 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/mariajdab/flight-price/helper"
 	"github.com/mariajdab/flight-price/internal/entity"
 )
 
 // this client use RAPID API
-const providerName = "google"
+const (
+	providerName = "google"
+	timeLayout   = "2006-01-02T15:04:05"
+)
 
 type Client struct {
 	httpClient http.Client
@@ -76,8 +79,7 @@ func (c *Client) getTopFlights(ctx context.Context, params entity.FlightSearchPa
 	req.Header.Set("x-rapidapi-host", host)
 	req.Header.Set("x-rapidapi-key", c.apikey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -103,89 +105,97 @@ func flightsPreProcess(flights []entity.OtherFlight) (entity.FlightSearchRespons
 		return entity.FlightSearchResponse{}, errors.New("empty flights list from google-flights")
 	}
 
-	// initialize with the first flight
-	cheapest := flights[0]
-	fastest := flights[0]
-	var fastestDuration = math.MaxInt64
+	var cheapest, fastest entity.Flight
 
 	resp := entity.FlightSearchResponse{
 		Flights: make([]entity.Flight, 0, len(flights)),
 	}
 
 	for _, f := range flights {
-		// check cheapest flight
-		if f.Price < cheapest.Price {
-			cheapest = f
+		flight, err := createFlightFromOtherFlight(f)
+		if err != nil {
+			log.Printf("warning: skipping google flight with invalid segments: %v", err)
+			continue
+		}
+		if len(flight.Segments) == 0 {
+			log.Println("warning: skipping google flight without segments")
+			continue
 		}
 
-		if f.Duration < fastestDuration {
-			fastest = f
-			fastestDuration = f.Duration
+		// check cheapest and fastest flight, the first valid one is the initial value
+		if len(resp.Flights) == 0 || flight.Price < cheapest.Price {
+			cheapest = flight
+		}
+		if len(resp.Flights) == 0 || flight.DurationMinutes < fastest.DurationMinutes {
+			fastest = flight
 		}
 
-		segments := createSegments(f.Segments)
+		resp.Flights = append(resp.Flights, flight)
+	}
 
-		// save flight data in a useful struct
-		resp.Flights = append(resp.Flights, entity.Flight{
-			Price:           f.Price,
-			DurationMinutes: f.Duration,
-			Segments:        segments,
-		})
+	if len(resp.Flights) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("no valid flights from google-flights")
 	}
 
 	resp.Provider = providerName
 	resp.Currency = entity.DefaultCurrency
-	resp.Cheapest = createFlightFromOtherFlight(cheapest)
-	resp.Fastest = createFlightFromOtherFlight(fastest)
+	resp.Cheapest = cheapest
+	resp.Fastest = fastest
 
 	return resp, nil
 }
 
-func createSegments(segmentsData []entity.SegmentGoogleF) []entity.Segment {
+func createSegments(segmentsData []entity.SegmentGoogleF) ([]entity.Segment, error) {
 	segments := make([]entity.Segment, 0, len(segmentsData))
 	for _, s := range segmentsData {
-		departureTime, err := formatDate(s.DepartureTime, s.DepartureDate)
+		departureAirport := helper.AirportCode(s.DepartureAirportCode, s.DepartureAirportName)
+		destinationAirport := helper.AirportCode(s.ArrivalAirportCode, s.ArrivalAirportName)
+
+		departureTime, departureZone, err := formatDate(s.DepartureTime, s.DepartureDate, departureAirport)
 		if err != nil {
-			log.Println("error in formatting departure time", err, departureTime)
-			departureTime = s.DepartureDate // not info abut time only date
+			return nil, fmt.Errorf("error in formatting departure time: %w", err)
 		}
 
-		arrivalTime, err := formatDate(s.ArrivalTime, s.ArrivalDate)
+		arrivalTime, arrivalZone, err := formatDate(s.ArrivalTime, s.ArrivalDate, destinationAirport)
 		if err != nil {
-			log.Println("error in formatting arrival time", err, departureTime)
-			arrivalTime = s.ArrivalTime // not info abut time only date
+			return nil, fmt.Errorf("error in formatting arrival time: %w", err)
 		}
 
 		segments = append(segments, entity.Segment{
-			DepartureAirport:   s.DepartureAirportName,
+			DepartureAirport:   departureAirport,
 			DepartureTime:      departureTime,
-			DestinationAirport: s.ArrivalAirportName, // Use DestinationAirportName
+			DestinationAirport: destinationAirport,
 			ArrivalTime:        arrivalTime,
+			MarketingCarrier:   s.AirlineCode,
+			OperatingCarrier:   s.OperatingAirlineCode,
+			FlightNumber:       s.FlightNumber,
+			Aircraft:           s.AircraftName,
+			TimeZoneUnknown:    !departureZone || !arrivalZone,
 		})
 	}
-	return segments
+	return segments, nil
 }
 
-func createFlightFromOtherFlight(tf entity.OtherFlight) entity.Flight {
-	segments := createSegments(tf.Segments)
+func createFlightFromOtherFlight(tf entity.OtherFlight) (entity.Flight, error) {
+	segments, err := createSegments(tf.Segments)
+	if err != nil {
+		return entity.Flight{}, err
+	}
 
 	return entity.Flight{
 		ProviderName:    providerName,
 		Price:           tf.Price,
 		DurationMinutes: tf.Duration,
+		Stops:           tf.Stops,
+		LayoverMinutes:  helper.LayoverMinutes(segments),
 		Segments:        segments,
-	}
+		Raw:             tf.Raw,
+		BookingURL:      helper.BookingURL(tf.BookingURL),
+	}, nil
 }
 
-func formatDate(timeStr, dateStr string) (string, error) {
+// formatDate joins the date and the time google sends separately, both local to the airport
+func formatDate(timeStr, dateStr, airport string) (time.Time, bool, error) {
 	dateTimeStr := fmt.Sprintf("%sT%s:00", dateStr, timeStr)
-	layout := "2006-01-02T15:04:05"
-
-	parsedTime, err := time.Parse(layout, dateTimeStr)
-	if err != nil {
-		return "", err
-	}
-
-	customFormat := parsedTime.Format("2006-01-02 15:04:05")
-	return customFormat, nil
+	return helper.ParseAirportTime(timeLayout, dateTimeStr, airport)
 }
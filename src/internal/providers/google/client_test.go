@@ -28,8 +28,9 @@ func TestClient_GetFlights_Success(t *testing.T) {
 			Data: entity.DataGoogle{
 				OtherFlights: []entity.OtherFlight{
 					{
-						Price:    200,
-						Duration: 180,
+						Price:      200,
+						Duration:   180,
+						BookingURL: "https://www.google.com/travel/flights/booking?tfs=abc",
 						Segments: []entity.SegmentGoogleF{
 							{
 								DepartureTime:        "10:00",
@@ -42,8 +43,9 @@ func TestClient_GetFlights_Success(t *testing.T) {
 						},
 					},
 					{
-						Price:    300,
-						Duration: 150,
+						Price:      300,
+						Duration:   150,
+						BookingURL: "javascript:alert(1)",
 						Segments: []entity.SegmentGoogleF{
 							{
 								DepartureTime:        "08:00",
@@ -80,7 +82,10 @@ func TestClient_GetFlights_Success(t *testing.T) {
 	assert.Len(t, result.Flights, 2)
 	assert.Equal(t, float64(200), result.Cheapest.Price)
 	assert.Equal(t, 150, result.Fastest.DurationMinutes)
-	assert.Equal(t, "2024-01-01 08:00:00", result.Fastest.Segments[0].DepartureTime)
+	assert.Equal(t, "2024-01-01T08:00:00-05:00", result.Fastest.Segments[0].DepartureTime.Format(time.RFC3339))
+	assert.Equal(t, "https://www.google.com/travel/flights/booking?tfs=abc", result.Cheapest.BookingURL)
+	// only the http links are kept
+	assert.Empty(t, result.Fastest.BookingURL)
 }
 
 func TestClient_GetFlights_HTTPError(t *testing.T) {
@@ -108,10 +113,14 @@ func TestFlightsPreProcess_EmptyList(t *testing.T) {
 }
 
 func TestFlightsPreProcess_CheapestAndFastest(t *testing.T) {
+	segments := []entity.SegmentGoogleF{{
+		DepartureAirportCode: "JFK", DepartureDate: "2024-01-01", DepartureTime: "10:00",
+		ArrivalAirportCode: "LAX", ArrivalDate: "2024-01-01", ArrivalTime: "13:00",
+	}}
 	flights := []entity.OtherFlight{
-		{Price: 500, Duration: 200},
-		{Price: 300, Duration: 150},
-		{Price: 400, Duration: 100},
+		{Price: 500, Duration: 200, Segments: segments},
+		{Price: 300, Duration: 150, Segments: segments},
+		{Price: 400, Duration: 100, Segments: segments},
 	}
 
 	resp, err := flightsPreProcess(flights)
@@ -122,7 +131,7 @@ func TestFlightsPreProcess_CheapestAndFastest(t *testing.T) {
 
 func TestCreateSegments_TimeFormatting(t *testing.T) {
 	segment := entity.SegmentGoogleF{
-		DepartureTime:        "invalid-time",
+		DepartureTime:        "10:00",
 		DepartureDate:        "2024-01-01",
 		ArrivalTime:          "15:00",
 		ArrivalDate:          "2024-01-01",
@@ -130,8 +139,23 @@ func TestCreateSegments_TimeFormatting(t *testing.T) {
 		ArrivalAirportName:   "LAX",
 	}
 
-	segments := createSegments([]entity.SegmentGoogleF{segment})
+	segments, err := createSegments([]entity.SegmentGoogleF{segment})
+	require.NoError(t, err)
 	require.Len(t, segments, 1)
-	assert.Equal(t, "2024-01-01", segments[0].DepartureTime)
-	assert.Equal(t, "2024-01-01 15:00:00", segments[0].ArrivalTime)
+	assert.Equal(t, "2024-01-01T10:00:00-05:00", segments[0].DepartureTime.Format(time.RFC3339))
+	assert.Equal(t, "2024-01-01T15:00:00-08:00", segments[0].ArrivalTime.Format(time.RFC3339))
+}
+
+func TestCreateSegments_InvalidTime(t *testing.T) {
+	segment := entity.SegmentGoogleF{
+		DepartureTime:        "invalid-time",
+		DepartureDate:        "2024-01-01",
+		ArrivalTime:          "15:00",
+		ArrivalDate:          "2024-01-01",
+		DepartureAirportName: "JFK",
+		ArrivalAirportName:   "LAX",
+	}
+
+	_, err := createSegments([]entity.SegmentGoogleF{segment})
+	require.Error(t, err)
 }
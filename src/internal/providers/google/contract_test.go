@@ -0,0 +1,50 @@
+package google
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/mariajdab/flight-price/internal/providers/providertest"
+)
+
+func TestContract(t *testing.T) {
+	providertest.Run(t, providertest.Stub{
+		New: func(baseURL string) providers.Flight {
+			return NewAdapterGoogleFlight(NewClient(http.Client{}, entity.Provider{
+				BaseURL: baseURL,
+				Apikey:  "test-api-key",
+				Timeout: time.Second,
+			}))
+		},
+		Criteria: entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2025-06-01"},
+		Handler: func(t *testing.T, search providertest.Response) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/flights/search-one-way" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+					return
+				}
+				providertest.Write(w, r, search)
+			})
+		},
+		Flights: `{"data": {"otherFlights": [
+			{"price": 120.5, "duration": 80, "stops": 0, "segments": [
+				{"departureAirportCode": "MAD", "arrivalAirportCode": "LIS", "departureDate": "2025-06-01", "departureTime": "07:00", "arrivalDate": "2025-06-01", "arrivalTime": "07:20", "airlineCode": "TP", "flightNumber": "1017"}
+			]},
+			{"price": 95, "duration": 240, "stops": 1, "segments": [
+				{"departureAirportCode": "MAD", "arrivalAirportCode": "OPO", "departureDate": "2025-06-01", "departureTime": "09:00", "arrivalDate": "2025-06-01", "arrivalTime": "09:15", "airlineCode": "IB", "flightNumber": "3100"},
+				{"departureAirportCode": "OPO", "arrivalAirportCode": "LIS", "departureDate": "2025-06-01", "departureTime": "11:00", "arrivalDate": "2025-06-01", "arrivalTime": "12:00", "airlineCode": "TP", "flightNumber": "1941"}
+			]}
+		]}}`,
+		Empty: `{"data": {"otherFlights": []}}`,
+		MissingLegs: `{"data": {"otherFlights": [
+			{"price": 50, "duration": 60, "stops": 0},
+			{"price": 60, "duration": 60, "stops": 0, "segments": []},
+			{"price": 120.5, "duration": 80, "stops": 0, "segments": [
+				{"departureAirportCode": "MAD", "arrivalAirportCode": "LIS", "departureDate": "2025-06-01", "departureTime": "07:00", "arrivalDate": "2025-06-01", "arrivalTime": "07:20", "airlineCode": "TP", "flightNumber": "1017"}
+			]}
+		]}}`,
+	})
+}
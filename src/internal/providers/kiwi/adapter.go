@@ -0,0 +1,40 @@
+package kiwi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mariajdab/flight-price/helper"
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+type Kiwi struct {
+	client *Client
+}
+
+func NewAdapterKiwi(client *Client) *Kiwi {
+	return &Kiwi{client: client}
+}
+
+func (p *Kiwi) Name() string {
+	return providerName
+}
+
+func (p *Kiwi) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	origin := helper.CityToKiwiCode(criteria.Origin)
+	destination := helper.CityToKiwiCode(criteria.Destination)
+
+	if origin == "" || destination == "" {
+		return entity.FlightSearchResponse{}, errors.New("origin or destination not supported")
+	}
+
+	criteria.Origin = origin
+	criteria.Destination = destination
+
+	flights, err := p.client.GetFlights(ctx, criteria)
+
+	if err != nil {
+		return entity.FlightSearchResponse{}, err
+	}
+	return flights, nil
+}
@@ -0,0 +1,226 @@
+package kiwi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mariajdab/flight-price/helper"
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+// this client use the kiwi TEQUILA search API
+const (
+	providerName = "kiwi"
+	// kiwi expects the dates as dd/mm/yyyy
+	dateLayout = "02/01/2006"
+)
+
+type Client struct {
+	httpClient http.Client
+	baseURL    string
+	apikey     string
+	timeout    time.Duration
+}
+
+func NewClient(httpClient http.Client, configProvider entity.Provider) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    configProvider.BaseURL,
+		apikey:     configProvider.Apikey,
+		timeout:    configProvider.Timeout,
+	}
+}
+
+func (c *Client) GetFlights(ctx context.Context, params entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	itineraries, err := c.searchItineraries(ctx, params)
+	if err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("error in searchItineraries: %w", err)
+	}
+
+	resp, err := itinerariesPreProcess(itineraries)
+	if err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("error in itinerariesPreProcess: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) searchItineraries(ctx context.Context, params entity.FlightSearchParam) ([]entity.ItineraryKiwi, error) {
+	const flightSearchEndpoint = "v2/search"
+
+	departure, err := time.Parse("2006-01-02", params.DateDeparture)
+	if err != nil {
+		return nil, fmt.Errorf("invalid departure date: %w", err)
+	}
+
+	baseURL, err := url.Parse(fmt.Sprintf("%s/%s", c.baseURL, flightSearchEndpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	// building the query parameters, the same day is the start and the end of the range
+	query := url.Values{}
+	query.Set("fly_from", params.Origin)
+	query.Set("fly_to", params.Destination)
+	query.Set("date_from", departure.Format(dateLayout))
+	query.Set("date_to", departure.Format(dateLayout))
+	query.Set("flight_type", "oneway")
+	query.Set("adults", entity.DefaultAdults)
+	query.Set("selected_cabins", "M") // economy
+	query.Set("curr", entity.DefaultCurrency)
+	query.Set("vehicle_type", "aircraft")
+	query.Set("sort", "price")
+	query.Set("limit", "50")
+
+	baseURL.RawQuery = query.Encode()
+	flightSearchURL := baseURL.String()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, flightSearchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("apikey", c.apikey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errorBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to search itineraries (status %d): %s", resp.StatusCode, string(errorBody))
+	}
+
+	var itineraries entity.FlightKiwiResp
+	if err := json.NewDecoder(resp.Body).Decode(&itineraries); err != nil {
+		log.Println("internal error during decode response from kiwi provider", err)
+		return nil, err
+	}
+
+	return itineraries.Data, nil
+}
+
+func itinerariesPreProcess(itineraries []entity.ItineraryKiwi) (entity.FlightSearchResponse, error) {
+	if len(itineraries) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("empty itineraries list from kiwi")
+	}
+
+	var cheapest, fastest entity.Flight
+
+	resp := entity.FlightSearchResponse{
+		Flights: make([]entity.Flight, 0, len(itineraries)),
+	}
+
+	for _, it := range itineraries {
+		flight, err := createFlightFromItinerary(it)
+		if err != nil {
+			log.Printf("warning: skipping kiwi itinerary %v with invalid segments: %v", it.ID, err)
+			continue
+		}
+		if len(flight.Segments) == 0 {
+			log.Printf("warning: skipping kiwi itinerary %v without segments", it.ID)
+			continue
+		}
+
+		// check cheapest and fastest flight, the first valid one is the initial value
+		if len(resp.Flights) == 0 || flight.Price < cheapest.Price {
+			cheapest = flight
+		}
+		if len(resp.Flights) == 0 || flight.DurationMinutes < fastest.DurationMinutes {
+			fastest = flight
+		}
+
+		resp.Flights = append(resp.Flights, flight)
+	}
+
+	if len(resp.Flights) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("no valid itineraries from kiwi")
+	}
+
+	resp.Provider = providerName
+	resp.Currency = entity.DefaultCurrency
+	resp.Cheapest = cheapest
+	resp.Fastest = fastest
+
+	return resp, nil
+}
+
+// createSegments converts the outbound segments, the UTC times are moved to the time zone of each airport
+func createSegments(route []entity.RouteKiwi) ([]entity.Segment, error) {
+	segments := make([]entity.Segment, 0, len(route))
+	for _, r := range route {
+		if r.Return != 0 {
+			continue
+		}
+
+		departureTime, err := airportTime(r.UTCDeparture, r.FlyFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid departure time: %w", err)
+		}
+		arrivalTime, err := airportTime(r.UTCArrival, r.FlyTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arrival time: %w", err)
+		}
+
+		segments = append(segments, entity.Segment{
+			DepartureAirport:   r.FlyFrom,
+			DepartureTime:      departureTime,
+			DestinationAirport: r.FlyTo,
+			ArrivalTime:        arrivalTime,
+			MarketingCarrier:   r.Airline,
+			OperatingCarrier:   r.OperatingCarrier,
+			FlightNumber:       strconv.Itoa(r.FlightNo),
+			Aircraft:           r.Equipment,
+		})
+	}
+	return segments, nil
+}
+
+func createFlightFromItinerary(it entity.ItineraryKiwi) (entity.Flight, error) {
+	segments, err := createSegments(it.Route)
+	if err != nil {
+		return entity.Flight{}, err
+	}
+
+	duration := it.Duration.Departure / 60
+	if duration == 0 && len(segments) > 0 {
+		duration = int(segments[len(segments)-1].ArrivalTime.Sub(segments[0].DepartureTime).Minutes())
+	}
+
+	return entity.Flight{
+		ProviderName:    providerName,
+		Price:           it.Price,
+		DurationMinutes: duration,
+		Stops:           max(len(segments)-1, 0),
+		LayoverMinutes:  helper.LayoverMinutes(segments),
+		Segments:        segments,
+		Ref:             &entity.ProviderRef{ID: it.ID},
+		Raw:             it.Raw,
+		BookingURL:      helper.BookingURL(it.DeepLink),
+	}, nil
+}
+
+// airportTime converts the UTC time to the time zone of the airport, it stays
+// in UTC when the airport is not in the dataset
+func airportTime(utc, airport string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, utc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := helper.AirportLocation(airport)
+	if err != nil {
+		log.Printf("warning: %v, the kiwi times are kept in UTC", err)
+		return t, nil
+	}
+	return t.In(loc), nil
+}
@@ -0,0 +1,87 @@
+package kiwi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetFlights_Success(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/search", r.URL.Path)
+		query := r.URL.Query()
+		assert.Equal(t, "MAD", query.Get("fly_from"))
+		assert.Equal(t, "LIS", query.Get("fly_to"))
+		assert.Equal(t, "01/06/2025", query.Get("date_from"))
+		assert.Equal(t, "01/06/2025", query.Get("date_to"))
+		assert.Equal(t, "USD", query.Get("curr"))
+		assert.Equal(t, "test-api-key", r.Header.Get("apikey"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"currency": "USD", "data": [
+			{"id": "1", "price": 120.5, "duration": {"departure": 0}, "deep_link": "https://www.kiwi.com/deep?flightsId=1", "route": [
+				{"flyFrom": "MAD", "flyTo": "LIS", "utc_departure": "2025-06-01T05:00:00.000Z", "utc_arrival": "2025-06-01T06:20:00.000Z", "airline": "TP", "operating_carrier": "TP", "flight_no": 1017, "equipment": "320", "return": 0},
+				{"flyFrom": "LIS", "flyTo": "MAD", "utc_departure": "2025-06-08T05:00:00.000Z", "utc_arrival": "2025-06-08T06:20:00.000Z", "airline": "TP", "flight_no": 1018, "return": 1}
+			]}
+		]}`))
+	}))
+	defer testServer.Close()
+
+	client := NewClient(http.Client{}, entity.Provider{
+		BaseURL: testServer.URL,
+		Apikey:  "test-api-key",
+		Timeout: time.Second,
+	})
+
+	resp, err := client.GetFlights(context.Background(), entity.FlightSearchParam{
+		Origin:        "MAD",
+		Destination:   "LIS",
+		DateDeparture: "2025-06-01",
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Flights, 1)
+
+	flight := resp.Flights[0]
+	assert.Equal(t, providerName, flight.ProviderName)
+	assert.Equal(t, 120.5, flight.Price)
+	// the duration is missing, it is the span of the outbound segments
+	assert.Equal(t, 80, flight.DurationMinutes)
+	assert.Equal(t, 0, flight.Stops)
+	assert.Equal(t, "https://www.kiwi.com/deep?flightsId=1", flight.BookingURL)
+
+	require.Len(t, flight.Segments, 1)
+	segment := flight.Segments[0]
+	assert.Equal(t, "1017", segment.FlightNumber)
+	assert.Equal(t, "320", segment.Aircraft)
+	// the UTC times are shown in the time zone of each airport
+	assert.Equal(t, "07:00", segment.DepartureTime.Format("15:04"))
+	assert.Equal(t, "07:20", segment.ArrivalTime.Format("15:04"))
+}
+
+func TestClient_GetFlights_InvalidDate(t *testing.T) {
+	client := NewClient(http.Client{}, entity.Provider{BaseURL: "http://127.0.0.1:0"})
+
+	_, err := client.GetFlights(context.Background(), entity.FlightSearchParam{
+		Origin:        "MAD",
+		Destination:   "LIS",
+		DateDeparture: "01/06/2025",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid departure date")
+}
+
+func TestAirportTime_UnknownAirportKeepsUTC(t *testing.T) {
+	departure, err := airportTime("2025-06-01T05:00:00.000Z", "QQQ")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-06-01T05:00:00Z", departure.Format(time.RFC3339))
+
+	departure, err = airportTime("2025-06-01T05:00:00.000Z", "MAD")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-06-01T07:00:00+02:00", departure.Format(time.RFC3339))
+}
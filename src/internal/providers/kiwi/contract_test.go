@@ -0,0 +1,52 @@
+package kiwi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/mariajdab/flight-price/internal/providers/providertest"
+)
+
+func TestContract(t *testing.T) {
+	providertest.Run(t, providertest.Stub{
+		New: func(baseURL string) providers.Flight {
+			return NewAdapterKiwi(NewClient(http.Client{}, entity.Provider{
+				BaseURL: baseURL,
+				Apikey:  "test-api-key",
+				Timeout: time.Second,
+			}))
+		},
+		Criteria: entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2025-06-01"},
+		Handler: func(t *testing.T, search providertest.Response) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/search" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+					return
+				}
+				providertest.Write(w, r, search)
+			})
+		},
+		Flights: `{"currency": "USD", "data": [
+			{"id": "1", "price": 120.5, "duration": {"departure": 4800}, "route": [
+				{"flyFrom": "MAD", "flyTo": "LIS", "utc_departure": "2025-06-01T05:00:00.000Z", "utc_arrival": "2025-06-01T06:20:00.000Z", "airline": "TP", "flight_no": 1017, "return": 0}
+			]},
+			{"id": "2", "price": 95, "duration": {"departure": 14400}, "route": [
+				{"flyFrom": "MAD", "flyTo": "OPO", "utc_departure": "2025-06-01T07:00:00.000Z", "utc_arrival": "2025-06-01T08:15:00.000Z", "airline": "IB", "flight_no": 3100, "return": 0},
+				{"flyFrom": "OPO", "flyTo": "LIS", "utc_departure": "2025-06-01T10:00:00.000Z", "utc_arrival": "2025-06-01T11:00:00.000Z", "airline": "TP", "flight_no": 1941, "return": 0}
+			]}
+		]}`,
+		Empty: `{"currency": "USD", "data": []}`,
+		MissingLegs: `{"currency": "USD", "data": [
+			{"id": "1", "price": 50, "duration": {"departure": 3600}, "route": []},
+			{"id": "2", "price": 60, "duration": {"departure": 3600}, "route": [
+				{"flyFrom": "LIS", "flyTo": "MAD", "utc_departure": "2025-06-08T05:00:00.000Z", "utc_arrival": "2025-06-08T07:20:00.000Z", "airline": "TP", "flight_no": 1018, "return": 1}
+			]},
+			{"id": "3", "price": 120.5, "duration": {"departure": 4800}, "route": [
+				{"flyFrom": "MAD", "flyTo": "LIS", "utc_departure": "2025-06-01T05:00:00.000Z", "utc_arrival": "2025-06-01T06:20:00.000Z", "airline": "TP", "flight_no": 1017, "return": 0}
+			]}
+		]}`,
+	})
+}
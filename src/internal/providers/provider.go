@@ -2,6 +2,7 @@ package providers
 
 import (
 	"context"
+	"errors"
 
 	"github.com/mariajdab/flight-price/internal/entity"
 )
@@ -9,3 +10,35 @@ import (
 type Flight interface {
 	SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error)
 }
+
+// Named is implemented by the providers that report their name, it is used to
+// identify the provider when the search fails
+type Named interface {
+	Name() string
+}
+
+var (
+	// ErrPricingNotSupported is returned when the provider can't confirm the price of its offers
+	ErrPricingNotSupported = errors.New("the provider does not support pricing")
+	// ErrOfferNotFound is returned when the offer is unknown or too old to be priced
+	ErrOfferNotFound = errors.New("offer not found or expired")
+)
+
+// Pricer is implemented by the providers that can confirm the current price of
+// an offer of their searches, the searched prices are often stale
+type Pricer interface {
+	PriceOffer(ctx context.Context, offer entity.StoredOffer) (entity.PricedOffer, error)
+}
+
+// CanPrice reports whether the provider, or the one it wraps, is a Pricer
+func CanPrice(p Flight) bool {
+	for {
+		wrapper, ok := p.(interface{ Unwrap() Flight })
+		if !ok {
+			break
+		}
+		p = wrapper.Unwrap()
+	}
+	_, ok := p.(Pricer)
+	return ok
+}
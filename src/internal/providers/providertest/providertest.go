@@ -0,0 +1,148 @@
+// Package providertest is the contract every flight provider must follow, the
+// providers run it from their tests against a stub server of their API
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorStatuses are answered by the search endpoint in the error cases
+var errorStatuses = []int{
+	http.StatusBadRequest,
+	http.StatusUnauthorized,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusServiceUnavailable,
+}
+
+// Response is the answer of the stub to the flight search request
+type Response struct {
+	Status int
+	Body   string
+	// Delay waits before answering, or until the request is cancelled
+	Delay time.Duration
+}
+
+// Write sends the response, the stubs use it for the search endpoint
+func Write(w http.ResponseWriter, r *http.Request, resp Response) {
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write([]byte(resp.Body))
+}
+
+// Stub describes how a provider is tested
+type Stub struct {
+	// New creates the provider with its requests sent to baseURL
+	New func(baseURL string) providers.Flight
+	// Criteria is a search of cities that the provider supports
+	Criteria entity.FlightSearchParam
+	// Handler answers the requests of the provider, the flight search with search
+	// and the other endpoints (e.g. the authentication) as the real API
+	Handler func(t *testing.T, search Response) http.Handler
+
+	// Flights has at least two valid flights with different prices and durations
+	Flights string
+	// Empty is a successful response without flights
+	Empty string
+	// MissingLegs has valid flights and flights without legs, itineraries or segments
+	MissingLegs string
+}
+
+// Run checks the provider in every case of the contract
+func Run(t *testing.T, stub Stub) {
+	t.Run("flights", func(t *testing.T) {
+		resp, err := search(t, stub, context.Background(), Response{Status: http.StatusOK, Body: stub.Flights})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(resp.Flights), 2)
+		CheckResponse(t, resp)
+	})
+
+	t.Run("empty results", func(t *testing.T) {
+		_, err := search(t, stub, context.Background(), Response{Status: http.StatusOK, Body: stub.Empty})
+		require.Error(t, err, "a search without flights is an error")
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		_, err := search(t, stub, context.Background(), Response{Status: http.StatusOK, Body: `{"data": [{"id": "1",`})
+		require.Error(t, err)
+	})
+
+	for _, status := range errorStatuses {
+		t.Run(fmt.Sprintf("status %d", status), func(t *testing.T) {
+			_, err := search(t, stub, context.Background(), Response{Status: status, Body: `{"message": "error"}`})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), strconv.Itoa(status))
+		})
+	}
+
+	t.Run("missing legs", func(t *testing.T) {
+		resp, err := search(t, stub, context.Background(), Response{Status: http.StatusOK, Body: stub.MissingLegs})
+		require.NoError(t, err)
+		CheckResponse(t, resp)
+	})
+
+	t.Run("context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := search(t, stub, ctx, Response{Status: http.StatusOK, Body: stub.Flights, Delay: 10 * time.Second})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "the error should wrap the context error: %v", err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func search(t *testing.T, stub Stub, ctx context.Context, resp Response) (entity.FlightSearchResponse, error) {
+	server := httptest.NewServer(stub.Handler(t, resp))
+	defer server.Close()
+
+	return stub.New(server.URL).SearchFlights(ctx, stub.Criteria)
+}
+
+// CheckResponse checks the invariants of the normalized response of a successful search
+func CheckResponse(t *testing.T, resp entity.FlightSearchResponse) {
+	t.Helper()
+
+	require.NotEmpty(t, resp.Flights)
+	assert.NotEmpty(t, resp.Provider)
+	assert.NotEmpty(t, resp.Cheapest.Segments, "the cheapest flight has no segments")
+	assert.NotEmpty(t, resp.Fastest.Segments, "the fastest flight has no segments")
+
+	for i, f := range resp.Flights {
+		assert.Greater(t, f.Price, 0.0, "flight %d has no price", i)
+		assert.Greater(t, f.DurationMinutes, 0, "flight %d has no duration", i)
+		assert.LessOrEqual(t, resp.Cheapest.Price, f.Price, "flight %d is cheaper than the cheapest", i)
+		assert.LessOrEqual(t, resp.Fastest.DurationMinutes, f.DurationMinutes, "flight %d is faster than the fastest", i)
+		assert.GreaterOrEqual(t, f.Stops, 0)
+
+		if !assert.NotEmpty(t, f.Segments, "flight %d has no segments", i) {
+			continue
+		}
+		for j, s := range f.Segments {
+			assert.NotEmpty(t, s.DepartureAirport, "flight %d segment %d", i, j)
+			assert.NotEmpty(t, s.DestinationAirport, "flight %d segment %d", i, j)
+			assert.False(t, s.DepartureTime.IsZero(), "flight %d segment %d has no departure time", i, j)
+			assert.True(t, s.ArrivalTime.After(s.DepartureTime), "flight %d segment %d arrives before departing", i, j)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"golang.org/x/time/rate"
+)
+
+// RateLimited waits for its turn before searching in the provider, the limit
+// is shared by all the searches of the server: normal, flexible, jobs and batches
+type RateLimited struct {
+	provider Flight
+	limiter  *rate.Limiter
+}
+
+// NewRateLimited allows requestsPerSecond searches in the provider with bursts of
+// the same size, a value of zero or less doesn't limit the provider
+func NewRateLimited(provider Flight, requestsPerSecond float64) *RateLimited {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if requestsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), max(1, int(math.Ceil(requestsPerSecond))))
+	}
+	return &RateLimited{provider: provider, limiter: limiter}
+}
+
+func (r *RateLimited) Name() string {
+	if named, ok := r.provider.(Named); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", r.provider)
+}
+
+func (r *RateLimited) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return entity.FlightSearchResponse{}, fmt.Errorf("waiting for the rate limit: %w", err)
+	}
+	return r.provider.SearchFlights(ctx, criteria)
+}
+
+// Unwrap returns the provider without the limit
+func (r *RateLimited) Unwrap() Flight {
+	return r.provider
+}
+
+// PriceOffer prices the offer in the provider sharing the limit of the searches
+func (r *RateLimited) PriceOffer(ctx context.Context, offer entity.StoredOffer) (entity.PricedOffer, error) {
+	pricer, ok := r.provider.(Pricer)
+	if !ok {
+		return entity.PricedOffer{}, ErrPricingNotSupported
+	}
+	if err := r.limiter.Wait(ctx); err != nil {
+		return entity.PricedOffer{}, fmt.Errorf("waiting for the rate limit: %w", err)
+	}
+	return pricer.PriceOffer(ctx, offer)
+}
@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) SearchFlights(context.Context, entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
+	p.calls++
+	return entity.FlightSearchResponse{Provider: "counting"}, nil
+}
+
+func TestRateLimited(t *testing.T) {
+	provider := &countingProvider{}
+	limited := NewRateLimited(provider, 20)
+
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		_, err := limited.SearchFlights(context.Background(), entity.FlightSearchParam{})
+		require.NoError(t, err)
+	}
+
+	// the first 20 are the burst, the other 5 wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Equal(t, 25, provider.calls)
+}
+
+func TestRateLimited_CancelledWhileWaiting(t *testing.T) {
+	provider := &countingProvider{}
+	limited := NewRateLimited(provider, 0.1)
+
+	_, err := limited.SearchFlights(context.Background(), entity.FlightSearchParam{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limited.SearchFlights(ctx, entity.FlightSearchParam{})
+	require.Error(t, err)
+	assert.Equal(t, 1, provider.calls)
+}
+
+func TestRateLimited_Unlimited(t *testing.T) {
+	provider := &countingProvider{}
+	limited := NewRateLimited(provider, 0)
+
+	for i := 0; i < 100; i++ {
+		_, err := limited.SearchFlights(context.Background(), entity.FlightSearchParam{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 100, provider.calls)
+}
+
+type pricingProvider struct {
+	countingProvider
+}
+
+func (p *pricingProvider) PriceOffer(_ context.Context, offer entity.StoredOffer) (entity.PricedOffer, error) {
+	return entity.PricedOffer{Token: offer.Ref.Token}, nil
+}
+
+func TestRateLimited_PriceOffer(t *testing.T) {
+	offer := entity.StoredOffer{Ref: entity.ProviderRef{Token: "token"}}
+
+	limited := NewRateLimited(&pricingProvider{}, 0)
+	assert.True(t, CanPrice(limited))
+	priced, err := limited.PriceOffer(context.Background(), offer)
+	require.NoError(t, err)
+	assert.Equal(t, "token", priced.Token)
+
+	limited = NewRateLimited(&countingProvider{}, 0)
+	assert.False(t, CanPrice(limited))
+	_, err = limited.PriceOffer(context.Background(), offer)
+	assert.ErrorIs(t, err, ErrPricingNotSupported)
+}
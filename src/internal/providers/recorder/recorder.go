@@ -0,0 +1,211 @@
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Mode string
+
+const (
+	// ModeRecord calls the providers and saves their responses
+	ModeRecord Mode = "record"
+	// ModeReplay returns the saved responses without calling the providers
+	ModeReplay Mode = "replay"
+)
+
+const contentType = "Content-Type"
+
+// ErrNotRecorded is returned in replay mode for the requests without a saved response
+var ErrNotRecorded = errors.New("the request was not recorded")
+
+// secretFields are removed from the form bodies before identifying the request, the
+// recordings are replayed without the credentials of the providers
+var secretFields = []string{"client_id", "client_secret"}
+
+// secretResponseFields are replaced in the JSON bodies of the responses before
+// saving them, the OAuth token of Amadeus would be valid for a while
+var secretResponseFields = []string{"access_token", "refresh_token", "id_token"}
+
+const redacted = "redacted"
+
+// recording is the file saved for each request, the headers of the request are
+// not saved because they have the api keys
+type recording struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Transport records the responses of the providers in a directory, one file per
+// request, and replays them to develop and test with no network
+type Transport struct {
+	mode Mode
+	dir  string
+	next http.RoundTripper
+}
+
+// NewTransport records or replays the requests in dir, next sends the requests in
+// record mode and it is http.DefaultTransport when nil
+func NewTransport(mode Mode, dir string, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{mode: mode, dir: dir, next: next}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(t.dir, fileName(req, key))
+
+	if t.mode == ModeReplay {
+		return replay(req, path)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := recording{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: resp.StatusCode,
+		Header: http.Header{contentType: resp.Header.Values(contentType)},
+		Body:   string(redactBody(body)),
+	}
+	if err := save(path, rec); err != nil {
+		return nil, fmt.Errorf("saving the recording of %s: %w", req.URL.Redacted(), err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func replay(req *http.Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL.Redacted())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+func save(path string, rec recording) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// the file is renamed so a replay never reads half a recording
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// redactBody replaces the secret fields of a JSON object, the other bodies are
+// saved as they are
+func redactBody(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	found := false
+	for _, field := range secretResponseFields {
+		if _, ok := fields[field]; ok {
+			fields[field] = json.RawMessage(`"` + redacted + `"`)
+			found = true
+		}
+	}
+	if !found {
+		return body
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// requestKey identifies the request by its method, url and body without the
+// secret fields. The body is read and put back in the request
+func requestKey(req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if strings.HasPrefix(req.Header.Get(contentType), "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for _, field := range secretFields {
+				form.Del(field)
+			}
+			body = []byte(form.Encode())
+		}
+	}
+
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s %s\n", req.Method, req.URL.String())
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))[:16], nil
+}
+
+// fileName is readable enough to find the recording of a request, e.g.
+// test.api.amadeus.com_GET_v2_shopping_flight-offers_3f2a9c0d1b2e4f5a.json
+func fileName(req *http.Request, key string) string {
+	path := strings.Trim(req.URL.Path, "/")
+	path = strings.NewReplacer("/", "_", "\\", "_", ".", "_").Replace(path)
+	return fmt.Sprintf("%s_%s_%s_%s.json", req.URL.Hostname(), req.Method, path, key)
+}
@@ -0,0 +1,120 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_RecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	get := func(client *http.Client) (int, string, error) {
+		resp, err := client.Get(server.URL + "/flights?from=MAD")
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	status, body, err := get(&http.Client{Transport: NewTransport(ModeRecord, dir, nil)})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"path":"/flights"}`, body)
+
+	server.Close()
+	replayClient := &http.Client{Transport: NewTransport(ModeReplay, dir, nil)}
+	status, body, err = get(replayClient)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"path":"/flights"}`, body)
+	assert.Equal(t, 1, calls)
+
+	_, err = replayClient.Get(server.URL + "/flights?from=LIS")
+	assert.ErrorIs(t, err, ErrNotRecorded)
+}
+
+func TestTransport_SecretsAreNotRecorded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"access_token":"token"}`))
+	}))
+	defer server.Close()
+
+	post := func(transport http.RoundTripper, secret string) (*http.Response, error) {
+		form := url.Values{"grant_type": {"client_credentials"}, "client_id": {"key"}, "client_secret": {secret}}
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/security/oauth2/token", strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer api-key")
+		return (&http.Client{Transport: transport}).Do(req)
+	}
+
+	dir := t.TempDir()
+	resp, err := post(NewTransport(ModeRecord, dir, nil), "real-secret")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "real-secret")
+	assert.NotContains(t, string(data), "api-key")
+
+	// the replay doesn't need the real credentials
+	resp, err = post(NewTransport(ModeReplay, dir, nil), "fake")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransport_TokensAreNotRecorded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type":"amadeusOAuth2Token","access_token":"real-token","expires_in":1799}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	resp, err := (&http.Client{Transport: NewTransport(ModeRecord, dir, nil)}).Post(server.URL+"/v1/security/oauth2/token", "application/x-www-form-urlencoded", nil)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	// the client still gets the real token
+	assert.Contains(t, string(body), "real-token")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "real-token")
+
+	resp, err = (&http.Client{Transport: NewTransport(ModeReplay, dir, nil)}).Post(server.URL+"/v1/security/oauth2/token", "application/x-www-form-urlencoded", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var token map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&token))
+	assert.Equal(t, "redacted", token["access_token"])
+	assert.Equal(t, 1799.0, token["expires_in"])
+}
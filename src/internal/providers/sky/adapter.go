@@ -16,6 +16,10 @@ func NewAdapterSkyRapid(client *Client) *SkyRapid {
 	return &SkyRapid{client: client}
 }
 
+func (p *SkyRapid) Name() string {
+	return providerName
+}
+
 func (p *SkyRapid) SearchFlights(ctx context.Context, criteria entity.FlightSearchParam) (entity.FlightSearchResponse, error) {
 	origin := helper.CityToSkyCode(criteria.Origin)
 	destination := helper.CityToSkyCode(criteria.Destination)
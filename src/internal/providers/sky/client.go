@@ -7,16 +7,19 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/mariajdab/flight-price/helper"
 	"github.com/mariajdab/flight-price/internal/entity"
 )
 
 // this client use RAPID API
-const providerName = "flights-sky"
+const (
+	providerName = "flights-sky"
+	timeLayout   = "2006-01-02T15:04:05"
+)
 
 type Client struct {
 	httpClient http.Client
@@ -76,8 +79,7 @@ func (c *Client) getFlightItineraries(ctx context.Context, params entity.FlightS
 	req.Header.Set("x-rapidapi-host", "flights-sky.p.rapidapi.com")
 	req.Header.Set("x-rapidapi-key", c.apikey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -101,78 +103,99 @@ func itineraryPreProcessResponse(itineraries []entity.FlightItinerary) (entity.F
 		return entity.FlightSearchResponse{}, errors.New("empty offers list")
 	}
 
-	// initialize with the first flight
-	cheapest := itineraries[0]
-	fastest := itineraries[0]
-	var fastestDuration = math.MaxInt64
+	var cheapest, fastest entity.Flight
 
 	resp := entity.FlightSearchResponse{
 		Flights: make([]entity.Flight, 0, len(itineraries)),
 	}
 
 	for _, it := range itineraries {
-		// check cheapest flight
-		if it.Price.Amount < cheapest.Price.Amount {
-			cheapest = it
-		}
-
 		// check for prevent panic
 		if len(it.Legs) == 0 {
 			log.Println("the flight offer does not have itineraries: ", it.ID)
 			continue
 		}
-		if it.Legs[0].Duration < fastestDuration {
-			fastest = it
-			fastestDuration = it.Legs[0].Duration
+
+		flight, err := createFlightFromItinerary(it)
+		if err != nil {
+			log.Printf("warning: skipping itinerary %v with invalid segments: %v", it.ID, err)
+			continue
+		}
+		if len(flight.Segments) == 0 {
+			log.Printf("warning: skipping itinerary %v without segments", it.ID)
+			continue
+		}
+
+		// check cheapest and fastest flight, the first valid one is the initial value
+		if len(resp.Flights) == 0 || flight.Price < cheapest.Price {
+			cheapest = flight
+		}
+		if len(resp.Flights) == 0 || flight.DurationMinutes < fastest.DurationMinutes {
+			fastest = flight
 		}
 
-		segments := createSegments(it.Legs[0].Segments)
+		resp.Flights = append(resp.Flights, flight)
+	}
 
-		// save flight data in a useful struct
-		resp.Flights = append(resp.Flights, entity.Flight{
-			DurationMinutes: it.Legs[0].Duration,
-			Segments:        segments,
-			Price:           it.Price.Amount,
-		})
+	if len(resp.Flights) == 0 {
+		return entity.FlightSearchResponse{}, errors.New("no valid itineraries in the list")
 	}
+
 	resp.Provider = providerName
 	resp.Currency = entity.DefaultCurrency
-	resp.Cheapest = createFlightFromItinerary(cheapest)
-	resp.Fastest = createFlightFromItinerary(fastest)
+	resp.Cheapest = cheapest
+	resp.Fastest = fastest
 
 	return resp, nil
 }
 
-func createSegments(segmentsData []entity.SegmentSky) []entity.Segment {
+// createSegments converts the segments, the times are local to each airport and come without offset
+func createSegments(segmentsData []entity.SegmentSky) ([]entity.Segment, error) {
 	segments := make([]entity.Segment, 0, len(segmentsData))
 	for _, s := range segmentsData {
+		departureAirport := helper.AirportCode(s.Origin.DisplayCode, s.Origin.Name)
+		destinationAirport := helper.AirportCode(s.Destination.DisplayCode, s.Destination.Name)
+
+		departureTime, departureZone, err := helper.ParseAirportTime(timeLayout, s.DepartureDate, departureAirport)
+		if err != nil {
+			return nil, fmt.Errorf("invalid departure time: %w", err)
+		}
+		arrivalTime, arrivalZone, err := helper.ParseAirportTime(timeLayout, s.ArrivalDate, destinationAirport)
+		if err != nil {
+			return nil, fmt.Errorf("invalid arrival time: %w", err)
+		}
+
 		segments = append(segments, entity.Segment{
-			DepartureAirport:   s.Origin.Name,
-			DepartureTime:      formatDate(s.DepartureDate),
-			DestinationAirport: s.Destination.Name, // Use DestinationAirportName
-			ArrivalTime:        formatDate(s.ArrivalDate),
+			DepartureAirport:   departureAirport,
+			DepartureTime:      departureTime,
+			DestinationAirport: destinationAirport,
+			ArrivalTime:        arrivalTime,
+			MarketingCarrier:   s.MarketingCarrier.AlternateID,
+			OperatingCarrier:   s.OperatingCarrier.AlternateID,
+			FlightNumber:       s.FlightNumber,
+			TimeZoneUnknown:    !departureZone || !arrivalZone,
 		})
 	}
-	return segments
+	return segments, nil
 }
 
 // createFlightFromItinerary is a helper function to create Flight from Itinerary
-func createFlightFromItinerary(it entity.FlightItinerary) entity.Flight {
+func createFlightFromItinerary(it entity.FlightItinerary) (entity.Flight, error) {
 	l := it.Legs[0]
-	segments := createSegments(l.Segments)
+	segments, err := createSegments(l.Segments)
+	if err != nil {
+		return entity.Flight{}, err
+	}
 
 	return entity.Flight{
 		ProviderName:    providerName,
 		DurationMinutes: l.Duration,
+		Stops:           l.StopCount,
+		LayoverMinutes:  helper.LayoverMinutes(segments),
 		Price:           it.Price.Amount,
 		Segments:        segments,
-	}
-}
-
-func formatDate(dateStr string) string {
-	layout := "2006-01-02T15:04:05"
-	parsedTime, _ := time.Parse(layout, dateStr)
-
-	formattedTime := parsedTime.Format("2006-01-02 15:04:05")
-	return formattedTime
+		Ref:             &entity.ProviderRef{ID: it.ID},
+		Raw:             it.Raw,
+		BookingURL:      helper.BookingURL(it.DeepLink),
+	}, nil
 }
@@ -0,0 +1,50 @@
+package sky
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/mariajdab/flight-price/internal/providers"
+	"github.com/mariajdab/flight-price/internal/providers/providertest"
+)
+
+func TestContract(t *testing.T) {
+	providertest.Run(t, providertest.Stub{
+		New: func(baseURL string) providers.Flight {
+			return NewAdapterSkyRapid(NewClient(http.Client{}, entity.Provider{
+				BaseURL: baseURL,
+				Apikey:  "test-api-key",
+				Timeout: time.Second,
+			}))
+		},
+		Criteria: entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: "2025-06-01"},
+		Handler: func(t *testing.T, search providertest.Response) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/flights/search-one-way" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+					return
+				}
+				providertest.Write(w, r, search)
+			})
+		},
+		Flights: `{"data": {"itineraries": [
+			{"id": "1", "price": {"raw": 120.5}, "legs": [{"durationInMinutes": 80, "stopCount": 0, "segments": [
+				{"origin": {"displayCode": "MAD"}, "destination": {"displayCode": "LIS"}, "departure": "2025-06-01T07:00:00", "arrival": "2025-06-01T07:20:00", "flightNumber": "1017", "marketingCarrier": {"alternateId": "TP"}}
+			]}]},
+			{"id": "2", "price": {"raw": 95}, "legs": [{"durationInMinutes": 240, "stopCount": 1, "segments": [
+				{"origin": {"displayCode": "MAD"}, "destination": {"displayCode": "OPO"}, "departure": "2025-06-01T09:00:00", "arrival": "2025-06-01T09:15:00", "flightNumber": "3100", "marketingCarrier": {"alternateId": "IB"}},
+				{"origin": {"displayCode": "OPO"}, "destination": {"displayCode": "LIS"}, "departure": "2025-06-01T11:00:00", "arrival": "2025-06-01T12:00:00", "flightNumber": "1941", "marketingCarrier": {"alternateId": "TP"}}
+			]}]}
+		]}}`,
+		Empty: `{"data": {"itineraries": []}}`,
+		MissingLegs: `{"data": {"itineraries": [
+			{"id": "1", "price": {"raw": 50}, "legs": []},
+			{"id": "2", "price": {"raw": 60}, "legs": [{"durationInMinutes": 60, "stopCount": 0, "segments": []}]},
+			{"id": "3", "price": {"raw": 120.5}, "legs": [{"durationInMinutes": 80, "stopCount": 0, "segments": [
+				{"origin": {"displayCode": "MAD"}, "destination": {"displayCode": "LIS"}, "departure": "2025-06-01T07:00:00", "arrival": "2025-06-01T07:20:00", "flightNumber": "1017", "marketingCarrier": {"alternateId": "TP"}}
+			]}]}
+		]}}`,
+	})
+}
@@ -0,0 +1,59 @@
+package searches
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+)
+
+const (
+	DefaultLimit = 20
+	// MaxPerUser is how many searches are kept for each user, the oldest are dropped
+	MaxPerUser = 100
+)
+
+// Repository stores the searches of the users
+type Repository interface {
+	Add(ctx context.Context, record entity.SearchRecord) error
+	// Recent returns the last searches of the user, newest first
+	Recent(ctx context.Context, userID string, limit int) ([]entity.SearchRecord, error)
+}
+
+// InMemoryRepository keeps the searches while the server is running
+type InMemoryRepository struct {
+	mu      sync.RWMutex
+	records map[string][]entity.SearchRecord
+}
+
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{records: make(map[string][]entity.SearchRecord)}
+}
+
+func (r *InMemoryRepository) Add(_ context.Context, record entity.SearchRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := append(r.records[record.UserID], record)
+	if len(records) > MaxPerUser {
+		records = records[len(records)-MaxPerUser:]
+	}
+	r.records[record.UserID] = records
+	return nil
+}
+
+func (r *InMemoryRepository) Recent(_ context.Context, userID string, limit int) ([]entity.SearchRecord, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := r.records[userID]
+	recent := make([]entity.SearchRecord, 0, min(limit, len(records)))
+	for i := len(records) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, records[i])
+	}
+	return recent, nil
+}
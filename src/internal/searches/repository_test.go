@@ -0,0 +1,38 @@
+package searches
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mariajdab/flight-price/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository_Recent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepository()
+
+	for i := 0; i < MaxPerUser+5; i++ {
+		require.NoError(t, repo.Add(ctx, entity.SearchRecord{
+			UserID: "maria",
+			Search: entity.FlightSearchParam{Origin: "Madrid", Destination: "Lisbon", DateDeparture: fmt.Sprintf("day-%d", i)},
+		}))
+	}
+	require.NoError(t, repo.Add(ctx, entity.SearchRecord{UserID: "other"}))
+
+	recent, err := repo.Recent(ctx, "maria", 3)
+	require.NoError(t, err)
+	require.Len(t, recent, 3)
+	assert.Equal(t, fmt.Sprintf("day-%d", MaxPerUser+4), recent[0].Search.DateDeparture)
+	assert.Equal(t, fmt.Sprintf("day-%d", MaxPerUser+2), recent[2].Search.DateDeparture)
+
+	all, err := repo.Recent(ctx, "maria", MaxPerUser*2)
+	require.NoError(t, err)
+	assert.Len(t, all, MaxPerUser)
+
+	none, err := repo.Recent(ctx, "nobody", 0)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}